@@ -1,6 +1,7 @@
 // Package main provides simple HTTP server created for testing purposes.
 //
 // By default it runs on "localhost:8080" and just write log message for each request body.
+// Request bodies larger than --max-body are rejected with 413 status.
 package main
 
 import (
@@ -8,6 +9,7 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"time"
 
 	"golang.org/x/net/netutil"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -16,11 +18,16 @@ import (
 var (
 	portFlag           string
 	maxConnectionsFlag int
+	maxBodyFlag        int64
+	readTimeoutFlag    time.Duration
 )
 
 func main() {
 	kingpin.Flag("port", "port").Default(":8080").StringVar(&portFlag)
 	kingpin.Flag("max-connections", "Maximum server connections\n").Default("10").IntVar(&maxConnectionsFlag)
+	kingpin.Flag("max-body", "Maximum request body size in bytes, 0 means unlimited\n").Default("0").Int64Var(&maxBodyFlag)
+	kingpin.Flag("read-timeout", "Maximum duration for reading the entire request, 0 means no timeout\n").
+		Default("0").DurationVar(&readTimeoutFlag)
 	kingpin.Parse()
 
 	l, err := net.Listen("tcp", portFlag)
@@ -31,17 +38,31 @@ func main() {
 
 	l = netutil.LimitListener(l, maxConnectionsFlag)
 
-	if err := http.Serve(l, http.HandlerFunc(defaultHandler)); err != nil {
+	srv := &http.Server{
+		Handler:     newHandler(maxBodyFlag),
+		ReadTimeout: readTimeoutFlag,
+	}
+	if err := srv.Serve(l); err != nil {
 		log.Fatalf("Unable to run test server, reason: %v", err)
 	}
 }
 
-func defaultHandler(resp http.ResponseWriter, req *http.Request) {
-	body, err := ioutil.ReadAll(req.Body)
-	if err != nil {
-		resp.WriteHeader(http.StatusInternalServerError)
-		return
+// newHandler creates handler which logs request bodies. Bodies larger than maxBody are rejected if maxBody is set.
+func newHandler(maxBody int64) http.HandlerFunc {
+	return func(resp http.ResponseWriter, req *http.Request) {
+		if maxBody > 0 {
+			req.Body = http.MaxBytesReader(resp, req.Body, maxBody)
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			if maxBody > 0 && int64(len(body)) >= maxBody {
+				resp.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+			resp.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Got message: %s\n", body)
+		resp.WriteHeader(http.StatusOK)
 	}
-	log.Printf("Got message: %s\n", body)
-	resp.WriteHeader(http.StatusOK)
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_MaxBody(t *testing.T) {
+	handler := newHandler(10)
+
+	t.Run("Oversized body", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", 11))))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("Body within limit", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", 10))))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		newHandler(0)(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", 1000))))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
@@ -5,7 +5,15 @@
 // A program that uses the "notification" library.
 // It reads stdin and send new messages every interval (which is configurable).
 // Each line are interpreted as a new message that needs to be notified about.
-// It also implements graceful shutdown on SIGINT.
+// Lines are read into a bounded buffer, so reading of the input doesn't wait for slow sends
+// until the buffer is full. It also implements graceful shutdown on SIGINT.
+//
+// With --route-prefix flag each line can be prefixed with a destination tag like "topicA:payload".
+// Payload of such line is sent to the URL configured for the tag using --route flag.
+// Lines without a known tag are sent to the default URL as is.
+//
+// With --request-timeout flag every message fails if it isn't delivered in time, so a hung server
+// doesn't occupy workers forever, while lines are still sent every interval.
 package main
 
 import (
@@ -26,9 +34,13 @@ import (
 )
 
 var (
-	urlFlag      string
-	intervalFlag time.Duration
-	traceFlag    bool
+	urlFlag         string
+	intervalFlag    time.Duration
+	traceFlag       bool
+	routePrefixFlag bool
+	routesFlag      map[string]string
+	bufferFlag      int
+	timeoutFlag     time.Duration
 )
 
 func main() {
@@ -38,6 +50,10 @@ func main() {
 	kingpin.Flag("url", "URL").Required().StringVar(&urlFlag)
 	kingpin.Flag("interval", "Notification interval\n").Short('i').Default("5s").DurationVar(&intervalFlag)
 	kingpin.Flag("trace", "Trace an application\n").Short('t').BoolVar(&traceFlag)
+	kingpin.Flag("route-prefix", "Route lines prefixed with \"tag:\" to the URL configured for the tag\n").BoolVar(&routePrefixFlag)
+	kingpin.Flag("route", "Destination URL for the tag in format tag=URL\n").StringMapVar(&routesFlag)
+	kingpin.Flag("buffer", "Number of read lines waiting to be sent\n").Default("1000").IntVar(&bufferFlag)
+	kingpin.Flag("request-timeout", "Time limit of every message including retries, 0 means no limit\n").DurationVar(&timeoutFlag)
 	kingpin.Parse()
 
 	if traceFlag {
@@ -53,7 +69,7 @@ func main() {
 	}
 
 	interrupt := make(chan struct{})
-	notify := notifier.New(urlFlag, nil)
+	notify := newNotifier(urlFlag, timeoutFlag)
 	notify.OnError(func(message []byte, err error) {
 		log.Printf("Unable to send message \"%s\": %v", message, err)
 	})
@@ -63,29 +79,85 @@ func main() {
 		close(interrupt)
 	})
 
-	reader := bufio.NewReader(os.Stdin)
-	var err error
-	var nextLine string
-readLoop:
-	for err != io.EOF {
+	lines := make(chan string, bufferFlag)
+	go readLines(os.Stdin, lines, interrupt)
+	sendLines(notify, lines, intervalFlag, interrupt)
+
+	notify.Wait()
+	log.Printf("Done\n")
+}
+
+// newNotifier creates notifier for url. Messages fail with notifier.TypeTimeout error
+// if they are not delivered within requestTimeout, unless it is zero.
+func newNotifier(url string, requestTimeout time.Duration) *notifier.Client {
+	return notifier.NewWithOptions(url, notifier.WithRequestTimeout(requestTimeout))
+}
+
+// readLines reads lines from r to the lines channel until EOF or interrupt.
+// It blocks while the channel is full, so lines are never dropped. Channel is closed on return.
+func readLines(r io.Reader, lines chan<- string, interrupt <-chan struct{}) {
+	defer close(lines)
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("Unable to read input: %v", err)
+			}
+			return
+		}
+		select {
+		case lines <- strings.TrimSuffix(line, "\n"):
+		case <-interrupt:
+			return
+		}
+	}
+}
+
+// sendLines sends every line from the lines channel waiting interval after each send
+// until channel is closed or interrupt.
+func sendLines(notify *notifier.Client, lines <-chan string, interval time.Duration, interrupt <-chan struct{}) {
+	for {
 		select {
 		case <-interrupt:
-			break readLoop
-		default:
-			nextLine, err = reader.ReadString('\n')
-			if err == nil {
-				msg := strings.TrimSuffix(nextLine, "\n")
-				n, err := notify.Notify([]byte(msg))
-				if err != nil {
-					log.Printf("Unable to handle message #%d: %s, reason: %v", n, msg, err)
-				}
-				time.Sleep(intervalFlag)
+			return
+		case msg, ok := <-lines:
+			if !ok {
+				return
+			}
+			n, err := notifyLine(notify, msg)
+			if err != nil {
+				log.Printf("Unable to handle message #%d: %s, reason: %v", n, msg, err)
+			}
+			select {
+			case <-time.After(interval):
+			case <-interrupt:
+				return
 			}
 		}
 	}
+}
 
-	notify.Wait()
-	log.Printf("Done\n")
+// notifyLine sends line using notifier.
+// If routing by prefix enabled it sends payload of tagged line to the URL configured for the tag.
+func notifyLine(notify *notifier.Client, line string) (int, error) {
+	if routePrefixFlag {
+		if tag, payload, ok := splitRoutePrefix(line); ok {
+			if url, found := routesFlag[tag]; found {
+				return notify.NotifyTo(url, []byte(payload))
+			}
+		}
+	}
+	return notify.Notify([]byte(line))
+}
+
+// splitRoutePrefix splits line in format "tag:payload" to tag and payload.
+func splitRoutePrefix(line string) (tag, payload string, ok bool) {
+	i := strings.Index(line, ":")
+	if i <= 0 {
+		return "", line, false
+	}
+	return line[:i], line[i+1:], true
 }
 
 func handleSignals(sig <-chan os.Signal, stop func()) {
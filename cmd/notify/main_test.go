@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/idexter/notifier-test-task/pkg/notifier"
+)
+
+// testServer collects bodies of received requests.
+type testServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	messages []string
+}
+
+func newTestServer() *testServer {
+	srv := &testServer{}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		srv.mu.Lock()
+		srv.messages = append(srv.messages, string(body))
+		srv.mu.Unlock()
+		writer.WriteHeader(http.StatusOK)
+	}))
+	return srv
+}
+
+func (s *testServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+func TestNotifyLine_RoutePrefix(t *testing.T) {
+	defaultSrv := newTestServer()
+	defer defaultSrv.Close()
+	topicSrv := newTestServer()
+	defer topicSrv.Close()
+
+	routePrefixFlag = true
+	routesFlag = map[string]string{"topicA": topicSrv.URL}
+	defer func() {
+		routePrefixFlag = false
+		routesFlag = nil
+	}()
+
+	notify := notifier.New(defaultSrv.URL, nil)
+	for _, line := range []string{"topicA:first", "untagged", "unknown:second", "topicA:third"} {
+		_, err := notifyLine(notify, line)
+		require.NoError(t, err)
+	}
+	notify.Wait()
+
+	assert.ElementsMatch(t, []string{"first", "third"}, topicSrv.received())
+	assert.ElementsMatch(t, []string{"untagged", "unknown:second"}, defaultSrv.received())
+}
+
+func TestSplitRoutePrefix(t *testing.T) {
+	tag, payload, ok := splitRoutePrefix("topicA:payload:with:colons")
+	assert.True(t, ok)
+	assert.Equal(t, "topicA", tag)
+	assert.Equal(t, "payload:with:colons", payload)
+
+	_, payload, ok = splitRoutePrefix(":payload")
+	assert.False(t, ok)
+	assert.Equal(t, ":payload", payload)
+}
+
+func TestReadAndSendLines(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	const count = 20
+	var input strings.Builder
+	expected := make([]string, count)
+	for i := range expected {
+		expected[i] = fmt.Sprintf("message %d", i)
+		input.WriteString(expected[i] + "\n")
+	}
+
+	notify := notifier.New(srv.URL, nil)
+	interrupt := make(chan struct{})
+	lines := make(chan string, 2)
+	readDone := make(chan struct{})
+	go func() {
+		readLines(strings.NewReader(input.String()), lines, interrupt)
+		close(readDone)
+	}()
+
+	const interval = 5 * time.Millisecond
+	start := time.Now()
+	sendLines(notify, lines, interval, interrupt)
+	notify.Wait()
+	<-readDone
+
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(count*interval), "sends should be paced")
+	assert.ElementsMatch(t, expected, srv.received())
+}
+
+func TestSendLines_Interrupt(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	notify := notifier.New(srv.URL, nil)
+	interrupt := make(chan struct{})
+	lines := make(chan string, 1)
+	lines <- "first"
+
+	done := make(chan struct{})
+	go func() {
+		sendLines(notify, lines, time.Hour, interrupt)
+		close(done)
+	}()
+	close(interrupt)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sendLines didn't return on interrupt")
+	}
+}
+
+func TestSendLines_RequestTimeout(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	hanging := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = ioutil.ReadAll(request.Body)
+		<-request.Context().Done()
+	}))
+	defer hanging.Close()
+
+	routePrefixFlag = true
+	routesFlag = map[string]string{"hang": hanging.URL}
+	defer func() {
+		routePrefixFlag = false
+		routesFlag = nil
+	}()
+
+	const timeout = time.Second
+	notify := newNotifier(srv.URL, timeout)
+	var mu sync.Mutex
+	var failed []string
+	notify.OnError(func(message []byte, err error) {
+		var nErr *notifier.NotifyErr
+		if assert.True(t, errors.As(err, &nErr)) {
+			assert.Equal(t, notifier.TypeTimeout, nErr.Type)
+		}
+		mu.Lock()
+		failed = append(failed, string(message))
+		mu.Unlock()
+	})
+
+	interrupt := make(chan struct{})
+	lines := make(chan string, 4)
+	for _, line := range []string{"hang:first", "second", "hang:third", "fourth"} {
+		lines <- line
+	}
+	close(lines)
+
+	sendLines(notify, lines, time.Millisecond, interrupt)
+	// Hung requests haven't timed out yet, so sendLines hasn't waited for them.
+	mu.Lock()
+	assert.Empty(t, failed, "hung requests must not stall sending")
+	mu.Unlock()
+	notify.Wait()
+
+	assert.ElementsMatch(t, []string{"second", "fourth"}, srv.received())
+	mu.Lock()
+	defer mu.Unlock()
+	assert.ElementsMatch(t, []string{"first", "third"}, failed)
+}
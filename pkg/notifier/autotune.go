@@ -0,0 +1,115 @@
+package notifier
+
+import (
+	"sync"
+)
+
+// defaultAutoTuneWindow is a number of results evaluated by workers tuner if ClientParams.AutoTuneWindow is not set.
+const defaultAutoTuneWindow = 10
+
+// workersTuner adjusts effective workers limit using error rate of sends.
+// It shrinks workers semaphore by keeping reserved tokens in it, so limit never exceeds its capacity.
+// Limit is halved when error rate in a window of results exceeds threshold
+// and grows by one after a window without errors.
+type workersTuner struct {
+	mu    sync.Mutex
+	slots chan struct{}
+	// reserved is a number of tokens taken by the tuner.
+	reserved int
+	// pending is a number of tokens to take as soon as workers release them.
+	pending int
+
+	windowSize int
+	results    int
+	failures   int
+	threshold  float64
+}
+
+// newWorkersTuner creates workersTuner for workers semaphore slots.
+func newWorkersTuner(slots chan struct{}, windowSize int, threshold float64) *workersTuner {
+	return &workersTuner{
+		slots:      slots,
+		windowSize: windowSize,
+		threshold:  threshold,
+	}
+}
+
+// limit returns effective workers limit.
+func (w *workersTuner) limit() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return cap(w.slots) - w.reserved - w.pending
+}
+
+// inUse returns number of tokens taken by workers.
+func (w *workersTuner) inUse() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.slots) - w.reserved
+}
+
+// record adds send result and adjusts limit when window is full.
+func (w *workersTuner) record(failed bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.results++
+	if failed {
+		w.failures++
+	}
+	if w.results < w.windowSize {
+		return
+	}
+	rate := float64(w.failures) / float64(w.results)
+	w.results, w.failures = 0, 0
+
+	limit := cap(w.slots) - w.reserved - w.pending
+	switch {
+	case rate > w.threshold:
+		w.shrink(limit - limit/2)
+	case rate == 0:
+		w.grow()
+	}
+}
+
+// shrink reduces limit by n but keeps at least one worker.
+func (w *workersTuner) shrink(n int) {
+	for ; n > 0 && cap(w.slots)-w.reserved-w.pending > 1; n-- {
+		select {
+		case w.slots <- struct{}{}:
+			w.reserved++
+		default:
+			w.pending++
+		}
+	}
+}
+
+// grow increases limit by one up to semaphore capacity.
+func (w *workersTuner) grow() {
+	switch {
+	case w.pending > 0:
+		w.pending--
+	case w.reserved > 0:
+		<-w.slots
+		w.reserved--
+	}
+}
+
+// keep reports if released worker token should be kept by the tuner to shrink the limit.
+func (w *workersTuner) keep() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.pending == 0 {
+		return false
+	}
+	w.pending--
+	w.reserved++
+	return true
+}
+
+// releaseWorker returns worker token to workers semaphore.
+func (c *Client) releaseWorker() {
+	if c.tuner != nil && c.tuner.keep() {
+		return
+	}
+	<-c.workersLimiter
+}
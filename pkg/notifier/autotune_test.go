@@ -0,0 +1,96 @@
+package notifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkersTuner(t *testing.T) {
+	slots := make(chan struct{}, 8)
+	tuner := newWorkersTuner(slots, 2, 0.5)
+
+	tuner.record(true)
+	assert.Equal(t, 8, tuner.limit(), "limit shouldn't change until window is full")
+	tuner.record(true)
+	assert.Equal(t, 4, tuner.limit())
+	tuner.record(true)
+	tuner.record(false)
+	assert.Equal(t, 4, tuner.limit(), "error rate equal to threshold shouldn't shrink limit")
+	for i := 0; i < 6; i++ {
+		tuner.record(true)
+	}
+	assert.Equal(t, 1, tuner.limit(), "limit should be at least 1")
+
+	for i := 0; i < 20; i++ {
+		tuner.record(false)
+	}
+	assert.Equal(t, 8, tuner.limit(), "limit should grow up to capacity")
+	assert.Empty(t, slots)
+}
+
+func TestWorkersTuner_Pending(t *testing.T) {
+	slots := make(chan struct{}, 2)
+	tuner := newWorkersTuner(slots, 1, 0.5)
+	slots <- struct{}{}
+	slots <- struct{}{}
+
+	tuner.record(true)
+	assert.Equal(t, 1, tuner.limit())
+	assert.True(t, tuner.keep(), "released token should be kept while limit is shrinking")
+	assert.False(t, tuner.keep())
+	assert.Equal(t, 1, tuner.inUse())
+}
+
+func TestNotifier_AutoTune(t *testing.T) {
+	var failing int32 = 1
+	var inFlight, maxInFlight int32
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if atomic.LoadInt32(&failing) == 1 {
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestsPerRate:   4,
+		BlockWhenFull:        true,
+		AutoTuneErrorRate:    0.5,
+		AutoTuneWindow:       4,
+	})
+
+	_, err := notifier.Notify(generateTestMessages(8)...)
+	require.NoError(t, err)
+	notifier.Wait()
+	assert.Equal(t, 1, notifier.tuner.limit())
+
+	atomic.StoreInt32(&failing, 0)
+	atomic.StoreInt32(&maxInFlight, 0)
+	_, err = notifier.Notify(generateTestMessages(4)...)
+	require.NoError(t, err)
+	notifier.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&maxInFlight))
+	assert.Equal(t, 2, notifier.tuner.limit())
+
+	_, err = notifier.Notify(generateTestMessages(12)...)
+	require.NoError(t, err)
+	notifier.Wait()
+	assert.Equal(t, 4, notifier.tuner.limit())
+}
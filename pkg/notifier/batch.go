@@ -0,0 +1,88 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Batch is a batch of messages scheduled by NotifyAsync.
+// It's sent using its own context derived from Client context, so it can be canceled separately.
+type Batch struct {
+	// Scheduled is a number of scheduled messages of the batch.
+	Scheduled int
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Cancel cancels sending of the batch messages. Other batches are not affected.
+// Canceled messages are reported to OnError handler with TypeContextCanceled error.
+func (b *Batch) Cancel() {
+	b.cancel()
+}
+
+// Done returns channel which is closed when all messages of the batch are handled.
+func (b *Batch) Done() <-chan struct{} {
+	return b.done
+}
+
+// Wait blocks until all messages of the batch are handled.
+func (b *Batch) Wait() {
+	<-b.done
+}
+
+// NotifyAsync works the same way as Notify, but returns Batch which can be used to wait for
+// or cancel just these messages. Batch is returned together with an error if some messages have not been scheduled.
+func (c *Client) NotifyAsync(messages ...[]byte) (*Batch, error) {
+	batch := &Batch{}
+	err := c.scheduleBatch(batch, messageTasks(c.URL(), messages), func(error) {})
+	return batch, err
+}
+
+// NotifyAndWait schedules messages the same way as Notify and blocks until all of them are handled.
+// It returns slice aligned with messages where every entry is an error of the message or nil if it has been delivered.
+// Messages which have not been scheduled have corresponding errors as well. Errors are also passed to OnError handler.
+func (c *Client) NotifyAndWait(messages ...[]byte) []error {
+	results := make([]error, len(messages))
+	var wg sync.WaitGroup
+	wg.Add(len(messages))
+	tasks := messageTasks(c.URL(), messages)
+	for i := range tasks {
+		i := i
+		tasks[i].done = func(err error) {
+			results[i] = err
+			wg.Done()
+		}
+	}
+	_, _ = c.schedule(tasks)
+	wg.Wait()
+	return results
+}
+
+// scheduleBatch schedules tasks using batch context. record is called with the result of every task.
+func (c *Client) scheduleBatch(batch *Batch, tasks []task, record func(err error)) error {
+	ctx, cancel := context.WithCancel(c.context())
+	batch.cancel = cancel
+	batch.done = make(chan struct{})
+	if len(tasks) == 0 {
+		cancel()
+		close(batch.done)
+		return nil
+	}
+
+	left := int64(len(tasks))
+	for i := range tasks {
+		tasks[i].ctx = ctx
+		tasks[i].done = func(err error) {
+			record(err)
+			if atomic.AddInt64(&left, -1) == 0 {
+				cancel()
+				close(batch.done)
+			}
+		}
+	}
+	n, err := c.schedule(tasks)
+	batch.Scheduled = n
+	return err
+}
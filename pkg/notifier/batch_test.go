@@ -0,0 +1,109 @@
+package notifier
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_NotifyAsync(t *testing.T) {
+	started := make(chan struct{}, 2)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		if strings.HasPrefix(string(body), "slow") {
+			started <- struct{}{}
+			<-request.Context().Done()
+		}
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   4,
+	})
+	var mu sync.Mutex
+	failed := make(map[string]error)
+	notifier.OnError(func(message []byte, err error) {
+		mu.Lock()
+		failed[string(message)] = err
+		mu.Unlock()
+	})
+
+	slow, err := notifier.NotifyAsync([]byte("slow 1"), []byte("slow 2"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, slow.Scheduled)
+	<-started
+	<-started
+
+	fast, err := notifier.NotifyAsync([]byte("fast 1"), []byte("fast 2"))
+	require.NoError(t, err)
+	fast.Wait()
+
+	select {
+	case <-slow.Done():
+		t.Fatal("slow batch shouldn't be done before cancel")
+	default:
+	}
+	slow.Cancel()
+	slow.Wait()
+	notifier.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, failed, 2)
+	assert.Contains(t, failed, "slow 1")
+	assert.Contains(t, failed, "slow 2")
+}
+
+func TestNotifier_NotifyAsyncEmpty(t *testing.T) {
+	notifier := New("http://localhost", &ClientParams{MaxConcurrentWorkers: 1})
+	batch, err := notifier.NotifyAsync()
+	require.NoError(t, err)
+	batch.Wait()
+	assert.Equal(t, 0, batch.Scheduled)
+}
+
+func TestNotifier_NotifyAndWait(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		if strings.HasPrefix(string(body), "fail") {
+			writer.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 5,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   5,
+		MaxMessageSize:       10,
+	})
+	errs := notifier.NotifyAndWait(
+		[]byte("ok 1"),
+		[]byte("fail 1"),
+		[]byte("too large message"),
+		[]byte("ok 2"),
+		[]byte("fail 2"),
+	)
+
+	require.Len(t, errs, 5)
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[3])
+	for _, i := range []int{1, 4} {
+		var nErr *NotifyErr
+		require.True(t, errors.As(errs[i], &nErr), i)
+		assert.Equal(t, http.StatusBadRequest, nErr.StatusCode, i)
+	}
+	var nErr *NotifyErr
+	require.True(t, errors.As(errs[2], &nErr))
+	assert.Equal(t, TypeInvalidMessage, nErr.Type)
+}
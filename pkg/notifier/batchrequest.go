@@ -0,0 +1,54 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// defaultBatchCountHeader is used if ClientParams.BatchCountHeader is empty.
+const defaultBatchCountHeader = "X-Batch-Count"
+
+// NotifyBatch sends messages as a single request, so receivers which accept newline-delimited
+// batches get them in one round trip. Messages are joined with newlines and the number of them is sent
+// in ClientParams.BatchCountHeader header. The batch is retried, encoded and limited by MaxMessageSize
+// as a single message, and handlers receive the joined body. Batches are never deduplicated or coalesced.
+// It returns number of scheduled messages, which is either zero or len(messages).
+// NotifyErr.Remaining contains the joined body if the batch has not been scheduled.
+// Batch rejected by MaxMessageSize or MaxInFlightBytes is passed to the error handler and its error is returned.
+// Messages containing newlines can't be told apart by the receiver, so the batch is rejected
+// with TypeInvalidMessage error without calling the error handler.
+func (c *Client) NotifyBatch(messages ...[]byte) (int, error) {
+	if len(messages) == 0 {
+		return 0, nil
+	}
+	for i, msg := range messages {
+		if bytes.IndexByte(msg, '\n') >= 0 {
+			return 0, &NotifyErr{
+				Type:    TypeInvalidMessage,
+				Message: "Message contains batch delimiter",
+				Err:     fmt.Errorf("message %d contains newline", i),
+			}
+		}
+	}
+	// done is called before schedule returns if the batch is rejected.
+	rejected := make(chan error, 1)
+	t := task{
+		url:        c.URL(),
+		message:    bytes.Join(messages, []byte("\n")),
+		batchCount: len(messages),
+		done: func(err error) {
+			select {
+			case rejected <- err:
+			default:
+			}
+		},
+	}
+	n, err := c.schedule([]task{t})
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, <-rejected
+	}
+	return len(messages), nil
+}
@@ -0,0 +1,94 @@
+package notifier
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_NotifyBatch(t *testing.T) {
+	type request struct {
+		count, body string
+	}
+	requests := make(chan request, 2)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		requests <- request{
+			count: req.Header.Get(defaultBatchCountHeader) + req.Header.Get("X-Items"),
+			body:  string(body),
+		}
+	}))
+	defer testSrv.Close()
+
+	params := &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+	}
+	notifier := New(testSrv.URL, params)
+	n, err := notifier.NotifyBatch(generateTestMessages(5)...)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	notifier.Wait()
+
+	r := <-requests
+	assert.Equal(t, "5", r.count)
+	assert.Equal(t, "msg 0\nmsg 1\nmsg 2\nmsg 3\nmsg 4", r.body)
+
+	t.Run("Custom header", func(t *testing.T) {
+		p := *params
+		p.BatchCountHeader = "X-Items"
+		notifier := New(testSrv.URL, &p)
+		_, err := notifier.NotifyBatch([]byte("a"), []byte("b"))
+		require.NoError(t, err)
+		notifier.Wait()
+
+		r := <-requests
+		assert.Equal(t, "2", r.count)
+		assert.Equal(t, "a\nb", r.body)
+	})
+
+	t.Run("Single messages", func(t *testing.T) {
+		notifier := New(testSrv.URL, params)
+		_, err := notifier.Notify([]byte("single"))
+		require.NoError(t, err)
+		notifier.Wait()
+
+		r := <-requests
+		assert.Empty(t, r.count)
+	})
+	t.Run("Rejected batch", func(t *testing.T) {
+		p := *params
+		p.MaxMessageSize = 5
+		notifier := New(testSrv.URL, &p)
+		failed := make(chan error, 1)
+		notifier.OnError(func(message []byte, err error) {
+			failed <- err
+		})
+		n, err := notifier.NotifyBatch([]byte("abc"), []byte("def"))
+		notifier.Wait()
+
+		assert.Zero(t, n)
+		assert.True(t, errors.Is(err, &NotifyErr{Type: TypeInvalidMessage}))
+		assert.Equal(t, err, <-failed)
+	})
+
+	t.Run("Delimiter in message", func(t *testing.T) {
+		notifier := New(testSrv.URL, params)
+		notifier.OnError(func(message []byte, err error) {
+			t.Errorf("unexpected error: %v", err)
+		})
+		n, err := notifier.NotifyBatch([]byte("a"), []byte("b\nc"))
+		notifier.Wait()
+
+		assert.Zero(t, n)
+		assert.True(t, errors.Is(err, &NotifyErr{Type: TypeInvalidMessage}))
+		assert.Empty(t, requests)
+	})
+}
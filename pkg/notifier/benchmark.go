@@ -0,0 +1,109 @@
+package notifier
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// BenchResult is a result of Client.Benchmark.
+type BenchResult struct {
+	// Sent is a number of sent messages including failed ones.
+	Sent int
+	// Failed is a number of messages which have not been delivered.
+	Failed int
+	// RPS is an achieved number of sent messages per second.
+	RPS float64
+	// ErrorRate is a share of failed messages.
+	ErrorRate float64
+	// P50, P90 and P99 are percentiles of send latency including retries.
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+
+	// MaxRPS is a ceiling of RPS allowed by configured rate limit. It is +Inf if rate is unlimited.
+	MaxRPS float64
+	// MaxConcurrentWorkers is a ceiling of concurrent requests.
+	MaxConcurrentWorkers int
+}
+
+// Benchmark sends as many copies of sample as configured limits allow for the duration and reports achieved throughput.
+// Messages are sent using the same workers and rate limiter as Notify, so Wait and WaitIdle wait for them too,
+// but OnError and OnComplete callbacks are not called and dead letters are not stored.
+func (c *Client) Benchmark(ctx context.Context, sample []byte, duration time.Duration) BenchResult {
+	// Deadline is not set on the context because rate limiter fails immediately
+	// if it has to wait longer than the deadline.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	timer := time.AfterFunc(duration, cancel)
+	defer timer.Stop()
+
+	var mu sync.Mutex
+	var latencies []time.Duration
+	var failed int
+	var wg sync.WaitGroup
+	start := time.Now()
+	for {
+		if err := c.waitWorker(ctx); err != nil {
+			break
+		}
+		if ctx.Err() != nil {
+			c.releaseWorker()
+			break
+		}
+		c.workers.Add(1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer c.workers.Done()
+			defer c.releaseWorker()
+			sendStart := time.Now()
+			_, err := c.send(task{ctx: ctx, url: c.URL(), message: sample})
+			if ctx.Err() != nil {
+				// Requests interrupted by the end of benchmark are not counted.
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			latencies = append(latencies, time.Since(sendStart))
+			if err != nil {
+				failed++
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	result := BenchResult{
+		Sent:                 len(latencies),
+		Failed:               failed,
+		MaxRPS:               float64(c.requestsLimiter.Limit()),
+		MaxConcurrentWorkers: c.Capacity(),
+	}
+	if c.requestsLimiter.Limit() == rate.Inf {
+		result.MaxRPS = math.Inf(1)
+	}
+	if result.Sent == 0 {
+		return result
+	}
+	result.RPS = float64(result.Sent) / elapsed.Seconds()
+	result.ErrorRate = float64(result.Failed) / float64(result.Sent)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	result.P50 = percentile(latencies, 0.5)
+	result.P90 = percentile(latencies, 0.9)
+	result.P99 = percentile(latencies, 0.99)
+	return result
+}
+
+// percentile returns p-th percentile of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
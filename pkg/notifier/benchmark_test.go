@@ -0,0 +1,67 @@
+package notifier
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifier_Benchmark(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	t.Run("Unlimited rate", func(t *testing.T) {
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 4,
+			MaxRequestsPerRate:   4,
+		})
+		result := notifier.Benchmark(context.Background(), []byte("sample"), 200*time.Millisecond)
+
+		assert.Greater(t, result.Sent, 0)
+		assert.Greater(t, result.RPS, float64(0))
+		assert.Equal(t, 0, result.Failed)
+		assert.Equal(t, float64(0), result.ErrorRate)
+		assert.True(t, math.IsInf(result.MaxRPS, 1))
+		assert.Equal(t, 4, result.MaxConcurrentWorkers)
+		assert.LessOrEqual(t, int64(result.P50), int64(result.P99))
+	})
+
+	t.Run("Rate limit is a ceiling", func(t *testing.T) {
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 4,
+			MaxRequestRate:       10 * time.Millisecond,
+			MaxRequestsPerRate:   1,
+		})
+		result := notifier.Benchmark(context.Background(), []byte("sample"), 300*time.Millisecond)
+
+		assert.Equal(t, float64(100), result.MaxRPS)
+		assert.Greater(t, result.RPS, float64(0))
+		assert.LessOrEqual(t, result.RPS, result.MaxRPS*1.1)
+	})
+	t.Run("Sends are tracked by Client", func(t *testing.T) {
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 2,
+			MaxRequestRate:       10 * time.Millisecond,
+			MaxRequestsPerRate:   1,
+		})
+		done := make(chan BenchResult, 1)
+		go func() {
+			done <- notifier.Benchmark(context.Background(), []byte("sample"), 200*time.Millisecond)
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.Error(t, notifier.WaitIdle(ctx), "Client must not be idle while benchmark is running")
+		result := <-done
+		assert.NoError(t, notifier.WaitIdle(context.Background()))
+		assert.Equal(t, notifier.Capacity(), result.MaxConcurrentWorkers)
+	})
+}
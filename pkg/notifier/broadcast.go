@@ -0,0 +1,23 @@
+package notifier
+
+// Broadcast sends the same message to every url concurrently. Unlike Notify it returns number of scheduled urls.
+// The result of every url is passed to the handler set by OnBroadcastResult, including urls which have
+// not been scheduled. Such messages are never deduplicated or coalesced, because they are expected to be the same.
+func (c *Client) Broadcast(message []byte, urls ...string) (int, error) {
+	tasks := make([]task, len(urls))
+	for i, url := range urls {
+		url := url
+		tasks[i] = task{url: url, message: message, done: func(err error) {
+			c.broadcast(url, message, err)
+		}}
+	}
+	return c.schedule(tasks)
+}
+
+// OnBroadcastResult sets handler which receives result of the message sent using Broadcast for every url.
+// err is nil if message has been delivered to the url.
+func (c *Client) OnBroadcastResult(handler func(url string, message []byte, err error)) {
+	if handler != nil {
+		c.broadcast = handler
+	}
+}
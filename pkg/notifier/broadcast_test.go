@@ -0,0 +1,62 @@
+package notifier
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_Broadcast(t *testing.T) {
+	received := make(chan string, 3)
+	newServer := func(status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			body, _ := ioutil.ReadAll(request.Body)
+			received <- string(body)
+			writer.WriteHeader(status)
+		}))
+	}
+	servers := []*httptest.Server{newServer(http.StatusOK), newServer(http.StatusAccepted), newServer(http.StatusBadRequest)}
+	urls := make([]string, len(servers))
+	for i, srv := range servers {
+		defer srv.Close()
+		urls[i] = srv.URL
+	}
+
+	notifier := New(urls[0], &ClientParams{
+		MaxConcurrentWorkers: 3,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   3,
+		DedupeCount:          10,
+	})
+	var mu sync.Mutex
+	results := make(map[string]error)
+	notifier.OnBroadcastResult(func(url string, message []byte, err error) {
+		assert.Equal(t, "alert", string(message))
+		mu.Lock()
+		results[url] = err
+		mu.Unlock()
+	})
+
+	n, err := notifier.Broadcast([]byte("alert"), urls...)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	notifier.Wait()
+	close(received)
+
+	require.Len(t, received, 3)
+	for message := range received {
+		assert.Equal(t, "alert", message)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, results, 3)
+	assert.NoError(t, results[urls[0]])
+	assert.NoError(t, results[urls[1]])
+	assert.Error(t, results[urls[2]])
+}
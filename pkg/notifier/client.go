@@ -4,19 +4,273 @@ package notifier
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"golang.org/x/sync/singleflight"
 	"golang.org/x/time/rate"
 )
 
 // ClientParams provides custom limits which you can set when create new Client instance.
 type ClientParams struct {
 	MaxConcurrentWorkers uint64
-	MaxRequestRate       time.Duration
-	MaxRequestsPerRate   int
+	// MaxRequestRate is an interval in which MaxRequestsPerRate requests are allowed.
+	// Zero value means there is no rate limit.
+	MaxRequestRate     time.Duration
+	MaxRequestsPerRate int
+	// InitialBurst is a number of first requests sent without rate limiting, so the first batch goes out quickly.
+	// Requests are paced by the rate limit only after initial burst is spent.
+	InitialBurst int
+	// MinInterval spaces consecutive requests by at least MinInterval if it is set.
+	// Unlike rate limit it doesn't allow bursts, requests are sent one by one.
+	MinInterval time.Duration
+
+	// MaxRetries is a number of additional attempts made when server responds with a retryable status
+	// or request fails with a connection error.
+	MaxRetries int
+	// RequestTimeout limits total time of sending a single message including retries if it is set.
+	// Timed out messages fail with TypeTimeout error which wraps context.DeadlineExceeded.
+	RequestTimeout time.Duration
+	// RetryBackoff is a delay before the first retry. Delay is doubled for every next retry.
+	// Zero means retries are made immediately.
+	RetryBackoff time.Duration
+	// RetryJitter randomly shortens every RetryBackoff delay by up to RetryJitter part of it, e.g. 0.5 means
+	// up to a half. It spreads retries of messages failed at the same time. It must be in [0, 1].
+	RetryJitter float64
+	// Rand is a source of RetryJitter randomness, e.g. with a fixed seed for reproducible delays.
+	// It's used under a lock, so it must not be used elsewhere. Source seeded by current time is used by default.
+	Rand *rand.Rand
+	// MaxRetryAfter limits delay requested by Retry-After header of 429 and 503 responses if it is set.
+	// Retry-After delay is used instead of RetryBackoff. If it exceeds MaxRetryAfter the message is failed.
+	MaxRetryAfter time.Duration
+	// MaxRetriesFunc overrides MaxRetries for every message if it is set. Zero disables retries of the message.
+	MaxRetriesFunc func(message []byte) int
+	// RetryOnStatus is a list of response status codes which should be retried.
+	// If it is empty DefaultRetryOnStatus is used.
+	RetryOnStatus []int
+	// SuccessStatusCodes is a list of response status codes which mean the message has been delivered.
+	// If it is empty any 2xx status is a success. Other statuses are reported as TypeSendError.
+	// It is ignored if ResponseValidator is set.
+	SuccessStatusCodes []int
+
+	// DedupeCount enables deduplication of messages if it is greater than zero.
+	// Message is dropped if the same message has been scheduled within last DedupeCount messages.
+	DedupeCount int
+
+	// SingleFlight makes concurrent sends of the same message to the same URL share one request.
+	// Every submission still gets its own callbacks with the shared result.
+	// Messages sent by NotifyItems with headers, NotifyContext and with IdempotencyKeys are never shared.
+	SingleFlight bool
+
+	// BlockWhenFull makes Notify block until a worker is available instead of returning TypeWorkersLimitExceeded error.
+	// It is the same as OverflowBlock policy.
+	BlockWhenFull bool
+	// OverflowPolicy defines what Notify does when workers limit and overflow queue are full.
+	OverflowPolicy OverflowPolicy
+
+	// MaxQueueDepth enables overflow queue if it is greater than zero.
+	// With OverflowReject policy messages which exceed workers limit are still returned in NotifyErr.Remaining,
+	// but first NotifyErr.Queued of them are also kept in the queue and sent as soon as workers become free.
+	// Messages which are not allowed by rate limiter yet are queued too, so they wait for the rate without
+	// holding a worker.
+	MaxQueueDepth int
+	// MaxInFlightBytes limits total size of messages which are being sent or waiting in the overflow queue
+	// if it is greater than zero. Messages which don't fit are reported to OnError handler with
+	// TypeWorkersLimitExceeded error. Bodies sent by NotifyReaders are not accounted.
+	MaxInFlightBytes int
+	// Sizer returns memory cost of the message used by MaxInFlightBytes accounting. It is len(message) by default.
+	Sizer func(message []byte) int
+
+	// Encoding is applied to every message before sending. Messages are sent as is by default.
+	Encoding Encoding
+	// PayloadFormat defines how encoded message is wrapped into request body. Messages are sent as is by default.
+	// FormatJSON sends them as JSON strings inside an object with PayloadField field and "application/json" Content-Type.
+	PayloadFormat PayloadFormat
+	// PayloadField is a name of the field which contains message in FormatJSON. It is "message" if empty.
+	PayloadField string
+	// Encoder builds request body and Content-Type of every message if it is set, e.g. for protobuf or msgpack.
+	// It replaces Encoding and PayloadFormat. Bodies sent by NotifyReaders are not encoded.
+	Encoder Encoder
+
+	// PauseErrorRate enables pausing of sends if it is greater than zero.
+	// When share of failed messages among last PauseWindow messages exceeds PauseErrorRate
+	// Client pauses sending for PauseCooldown and then resumes automatically.
+	PauseErrorRate float64
+	PauseWindow    int
+	PauseCooldown  time.Duration
+
+	// OfflineAfterFailures enables offline mode if it is greater than zero.
+	// After OfflineAfterFailures consecutive connection failures Client stops sending and messages wait
+	// instead of failing, while the server is probed with HEAD request every OfflineProbeInterval (5s by default).
+	// Probe is sent to OfflineProbeURL or to Client URL if it's empty. Once the server responds waiting messages are sent.
+	// Keep in mind that waiting messages occupy workers limit the same way as in maintenance mode.
+	OfflineAfterFailures int
+	OfflineProbeInterval time.Duration
+	OfflineProbeURL      string
+
+	// AutoTuneErrorRate enables auto-tuning of workers limit if it is greater than zero.
+	// Results are evaluated in windows of AutoTuneWindow messages (10 by default). Limit is halved when share of failed
+	// messages in the window exceeds AutoTuneErrorRate and grows by one after a window without errors up to MaxConcurrentWorkers.
+	AutoTuneErrorRate float64
+	AutoTuneWindow    int
+
+	// Signer is used to sign every request if it is set.
+	Signer Signer
+
+	// HostHeader overrides Host header of every request independently of the URL, e.g. for virtual hosts.
+	HostHeader string
+	// BatchCountHeader is set to the number of messages of every request sent by NotifyBatch.
+	// It is "X-Batch-Count" if empty.
+	BatchCountHeader string
+
+	// DeadLetterCapacity enables collecting of undelivered messages if it is greater than zero.
+	// Only last DeadLetterCapacity messages are kept.
+	DeadLetterCapacity int
+
+	// CoalesceConsecutive enables merging of identical consecutive messages into a single request.
+	// Such request has X-Repeat-Count header with the number of merged messages.
+	// If CoalesceWindow is set the last message is held for the window, so it can be merged with following calls.
+	CoalesceConsecutive bool
+	CoalesceWindow      time.Duration
+
+	// Partitions enables sharding of messages if it is greater than zero.
+	// Every message is sent to URL with "/p{N}" path appended, where N is a hash of the message modulo Partitions.
+	Partitions int
+
+	// HTTPClient is used to send requests instead of the client created by New if it is set,
+	// e.g. to set a timeout, cookie jar or instrumented transport. Transport related params below
+	// are not applied to it, but TransportWrapper is.
+	HTTPClient *http.Client
+	// DialTimeout limits time of establishing new connections if it is set.
+	// It is applied only to the transport created by New.
+	DialTimeout time.Duration
+	// H2CUpgrade makes Client send requests over cleartext HTTP/2 connections established using
+	// HTTP/1.1 "Upgrade: h2c" handshake, for servers which don't accept HTTP/2 with prior knowledge.
+	// Every connection is upgraded by bodiless OPTIONS request to the URL of the first message,
+	// which the server handles as usual, and the response to it is discarded. One connection is kept per host.
+	// Only http URLs are supported. It is applied only to the transport created by New.
+	H2CUpgrade bool
+	// PinnedCertSHA256 is a list of SHA-256 hashes of allowed server leaf certificates.
+	// If it's set connection fails when server certificate doesn't match any of them.
+	// It is applied only to the transport created by New in addition to regular certificate verification.
+	PinnedCertSHA256 [][]byte
+	// MaxRequestsPerConn closes connection after it has been used for MaxRequestsPerConn requests if it is set,
+	// so requests are spread across connections. It is applied only to the transport created by New.
+	MaxRequestsPerConn int
+	// MaxTotalConns limits number of open connections across all hosts if it is set.
+	// Requests wait for a free connection when the limit is reached. It is applied only to the transport created by New.
+	MaxTotalConns int
+	// CloseIdleOnStop makes StopGrace and Close close idle connections after in-flight requests complete,
+	// so servers see connections closed cleanly instead of dropped when the process exits.
+	// Keep in mind that http.DefaultTransport used by default is shared by all clients of the process.
+	CloseIdleOnStop bool
+
+	// ResponseValidator decides if message has been delivered using response status code and body.
+	// Body is read up to 1MB. If validator is set it overrides status based check,
+	// but messages with retryable status are still retried if validator returns an error.
+	ResponseValidator func(statusCode int, body []byte) error
+	// Accept is sent in Accept header of every request if it is set.
+	Accept string
+	// ResponseDecoders decode bodies of successful responses by media type of their Content-Type header,
+	// e.g. "application/json". Decoded acknowledgement is passed to the handler set by Client.OnAck.
+	// Responses without a decoder are handled as usual. If decoding fails the message fails with TypeSendError.
+	ResponseDecoders map[string]ResponseDecoder
+
+	// TransportWrapper wraps transport used by the Client if it is set.
+	// It can be used to add authentication or instrumentation, see oauth package for example.
+	TransportWrapper func(http.RoundTripper) http.RoundTripper
+
+	// CorrelationIDKey is a context key of correlation ID. If context passed to NotifyContext has a value
+	// for the key it is sent in CorrelationIDHeader header.
+	CorrelationIDKey    interface{}
+	CorrelationIDHeader string
+
+	// SendStartHeader enables X-Client-Send-Start header with RFC 3339 timestamp of the moment request is sent.
+	// Server can use it to separate network time from processing time.
+	SendStartHeader bool
+
+	// MaxMessageSize rejects messages larger than MaxMessageSize bytes with TypeInvalidMessage error if it is set.
+	MaxMessageSize int
+
+	// ShadowURL enables mirroring of every message to the URL if it is set.
+	// Copies are sent once without rate limiting, their results are ignored.
+	ShadowURL string
+
+	// IdempotencyKeys enables Idempotency-Key header with a random key generated for every message.
+	// The key is the same for all retries of the message. Use Client.OnKeyedResult to get results by keys.
+	IdempotencyKeys bool
+
+	// StreamFrameSize enables length-prefixed framing of bodies sent by NotifyReaders if it is greater than zero.
+	// Body is split into frames of up to StreamFrameSize bytes, see FrameWriter for the wire format.
+	StreamFrameSize int
+
+	// SerializePerHost allows only one in-flight request per destination host, while requests
+	// to distinct hosts are still sent concurrently. Waiting messages occupy workers limit.
+	SerializePerHost bool
+
+	// BodyPrefix and BodySuffix are added to every encoded message before it's compressed and signed,
+	// e.g. start and end markers required by line protocol receivers. Bodies sent by NotifyReaders are sent as is.
+	BodyPrefix []byte
+	BodySuffix []byte
+
+	// Compress enables gzip compression of message bodies. Compressed requests have "Content-Encoding: gzip" header.
+	// Bodies sent by NotifyReaders are never compressed.
+	Compress bool
+	// CompressMinSize disables Compress for bodies shorter than CompressMinSize bytes,
+	// because gzip header and footer make small bodies larger.
+	CompressMinSize int
+	// CompressFunc overrides Compress and CompressMinSize for every message if it is set, so compression can be skipped
+	// for small or already compressed messages.
+	CompressFunc func(message []byte) bool
+
+	// RateKeyFunc enables separate rate limits for every key returned by the func, e.g. tenant ID of the message.
+	// Every key gets its own MaxRequestRate and MaxRequestsPerRate budget instead of the Client-wide one.
+	// Bodies sent by NotifyReaders are limited by the Client-wide budget.
+	RateKeyFunc func(message []byte) string
+
+	// Headers are added to every request. They override Content-Type set by Encoding and PayloadFormat.
+	// Headers are copied by New, so changing them afterwards doesn't affect the Client.
+	Headers http.Header
+
+	// AuthToken is sent in "Authorization: Bearer <token>" header of every request if it is set.
+	// Authorization header set by Headers takes precedence over AuthToken.
+	AuthToken string
+	// AuthTokenFunc overrides AuthToken if it is set. It's called once per message, so tokens can be rotated.
+	// All retries of the message use the same token.
+	AuthTokenFunc func() string
+	// BasicAuthUser and BasicAuthPassword are sent using HTTP Basic authentication if both are set.
+	// Authorization header set by Headers or bearer token set by AuthToken take precedence over them.
+	BasicAuthUser     string
+	BasicAuthPassword string
+
+	// Method is an HTTP method of requests. It is POST if empty or not one of GET, POST, PUT, PATCH and DELETE.
+	Method string
+	// FailFastOnRequestError makes Client stop on the first error of request creation, e.g. because of invalid URL,
+	// so remaining messages fail with TypeContextCanceled error instead of repeating the same error one by one.
+	FailFastOnRequestError bool
+
+	// ContextFunc derives context of requests sending the message if it is set, e.g. to attach values or a deadline.
+	// Requests are canceled when Client or NotifyContext context is done regardless of returned context.
+	ContextFunc func(ctx context.Context, message []byte) context.Context
+
+	// ErrorHandler is called for every message which has not been delivered, see Client.OnError.
+	ErrorHandler func(message []byte, err error)
+	// RequireErrorHandler makes NewValidated fail if ErrorHandler is not set,
+	// so failed messages can't be lost silently.
+	RequireErrorHandler bool
 }
 
 // DefaultParams client parameters which is used by default.
@@ -28,50 +282,437 @@ var DefaultParams = &ClientParams{
 	MaxRequestsPerRate:   100,
 }
 
+// DefaultRetryOnStatus response status codes which are retried by default.
+var DefaultRetryOnStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// sendStartHeader contains time of sending the request if ClientParams.SendStartHeader is set.
+const sendStartHeader = "X-Client-Send-Start"
+
+// maxResponseBodySize limits size of response body which is read by the Client.
+const maxResponseBodySize = 1 << 20
+
 // Client implements HTTP notifier.
 // Use New function to create properly initialized instance.
 type Client struct {
-	url         string
+	url         atomic.Value
 	notifyError func(message []byte, err error)
+	mapResult   func(id string, message []byte, err error)
+	broadcast   func(url string, message []byte, err error)
+	complete    func(message []byte, duration time.Duration, err error)
+	keyedResult func(key string, statusCode int, err error)
+	created     func(message []byte, location string)
+	success     func(message []byte, resp *http.Response)
+	ack         func(message []byte, ack interface{})
 	client      *http.Client
 
+	maxRetries     int
+	retriesFunc    func(message []byte) int
+	retryBackoff   time.Duration
+	retryJitter    float64
+	jitterSource   *jitterSource
+	maxRetryAfter  time.Duration
+	requestTimeout time.Duration
+	retryOnStatus  map[int]struct{}
+	retryDecider   func(message []byte, err error) bool
+	successStatus  map[int]struct{}
+	dedupe         *dedupeWindow
+	flight         *singleflight.Group
+	hostLocks      *hostLocks
+	queue          *overflowQueue
+	queueDrained   <-chan struct{}
+	inFlight       *byteBudget
+	sizer          func(message []byte) int
+	blockWhenFull  bool
+	overflowPolicy OverflowPolicy
+	encoder        Encoder
+	pause          *errorPause
+	tuner          *workersTuner
+	signer         Signer
+	hostHeader     string
+	batchHeader    string
+	metrics        *metrics
+	// statsdMu guards statsd, which is replaced by EnableStatsd.
+	statsdMu       sync.RWMutex
+	statsd         *statsdEmitter
+	deadLetters    *deadLetterStore
+	deadLetterFile *deadLetterFile
+	coalesce       *coalescer
+	senders        senders
+	partitions     int
+	maintenance    gate
+	offline        *offlineDetector
+
+	// deadLetterFileMu guards deadLetterFile, which is replaced by PersistDeadLetters.
+	deadLetterFileMu sync.RWMutex
+
+	// heartbeatMu guards stopHeartbeat, which is replaced by every Heartbeat call.
+	heartbeatMu   sync.Mutex
+	stopHeartbeat context.CancelFunc
+
+	responseValidator   func(statusCode int, body []byte) error
+	accept              string
+	decoders            map[string]ResponseDecoder
+	correlationIDKey    interface{}
+	correlationIDHeader string
+	sendStartHeader     bool
+	maxMessageSize      int
+	shadowURL           string
+	offlineProbeURL     string
+	idempotencyKeys     bool
+	streamFrameSize     int
+	bodyPrefix          []byte
+	bodySuffix          []byte
+	compress            bool
+	compressFunc        func(message []byte) bool
+	compressMinSize     int
+	contextFunc         func(ctx context.Context, message []byte) context.Context
+	method              string
+	failFast            bool
+	closeIdleOnStop     bool
+	headers             http.Header
+	authToken           string
+	authTokenFunc       func() string
+	basicAuthUser       string
+	basicAuthPassword   string
+
+	// params are effective params used to create the Client.
+	params ClientParams
+
+	// ctxMu guards ctx and cancel, which are replaced by Restart.
+	ctxMu           sync.RWMutex
 	ctx             context.Context
 	cancel          context.CancelFunc
-	workers         sync.WaitGroup
+	stopping        int32
+	draining        int32
+	workers         workGroup
 	workersLimiter  chan struct{}
 	requestsLimiter *rate.Limiter
+	rateKeyFunc     func(message []byte) string
+	keyedLimiters   *keyedLimiters
+	pacer           *pacer
+	initialBurst    int64
+	lastSent        int64
 }
 
 // New creates new Client instance with configured "URL" and provided ClientParams.
-// If params is nil it will use DefaultParams.
+// If params is nil it will use DefaultParams. See NewWithOptions for configuration by options.
 func New(url string, params *ClientParams) *Client {
-	return create(url, params, http.DefaultTransport)
+	return NewWithOptions(url, WithParams(params))
+}
+
+// newTransport returns http.DefaultTransport or its copy adjusted to params.
+func newTransport(params *ClientParams) http.RoundTripper {
+	if params != nil && params.H2CUpgrade {
+		return newH2CUpgradeTransport(params)
+	}
+	if params == nil || params.DialTimeout <= 0 && len(params.PinnedCertSHA256) == 0 && params.MaxRequestsPerConn <= 0 &&
+		params.MaxTotalConns <= 0 {
+		return http.DefaultTransport
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	configureTransport(transport, params)
+	if params.MaxRequestsPerConn > 0 {
+		return &connLimiter{next: transport, maxRequests: int32(params.MaxRequestsPerConn)}
+	}
+	return transport
+}
+
+// configureTransport applies transport related params to the transport.
+func configureTransport(transport *http.Transport, params *ClientParams) {
+	if params.DialTimeout > 0 {
+		transport.DialContext = (&net.Dialer{
+			Timeout:   params.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	}
+	if len(params.PinnedCertSHA256) > 0 {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{} //nolint: gosec
+		} else {
+			transport.TLSClientConfig = transport.TLSClientConfig.Clone()
+		}
+		transport.TLSClientConfig.VerifyPeerCertificate = verifyPinnedCert(params.PinnedCertSHA256)
+	}
+	if params.MaxTotalConns > 0 {
+		transport.DialContext = limitingDialer(transport.DialContext, make(chan struct{}, params.MaxTotalConns), transport.CloseIdleConnections)
+	}
+	if params.MaxRequestsPerConn > 0 {
+		transport.DialContext = countingDialer(transport.DialContext)
+	}
+}
+
+// NewValidated works the same way as New, but returns an error if params are invalid.
+func NewValidated(url string, params *ClientParams) (*Client, error) {
+	if params != nil {
+		if err := params.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return New(url, params), nil
+}
+
+// Validate checks that params are consistent.
+func (p *ClientParams) Validate() error {
+	switch {
+	case p.MaxRequestRate < 0:
+		return errors.New("invalid params: MaxRequestRate must not be negative")
+	case p.MaxRequestsPerRate < 0:
+		return errors.New("invalid params: MaxRequestsPerRate must not be negative")
+	case p.MinInterval < 0:
+		return errors.New("invalid params: MinInterval must not be negative")
+	case p.InitialBurst < 0:
+		return errors.New("invalid params: InitialBurst must not be negative")
+	case p.MaxRetries < 0:
+		return errors.New("invalid params: MaxRetries must not be negative")
+	case p.RequestTimeout < 0:
+		return errors.New("invalid params: RequestTimeout must not be negative")
+	case p.RetryJitter < 0 || p.RetryJitter > 1:
+		return errors.New("invalid params: RetryJitter must be in [0, 1]")
+	case p.MaxRetryAfter < 0:
+		return errors.New("invalid params: MaxRetryAfter must not be negative")
+	case p.PauseErrorRate < 0 || p.PauseErrorRate > 1:
+		return errors.New("invalid params: PauseErrorRate must be between 0 and 1")
+	case p.AutoTuneErrorRate < 0 || p.AutoTuneErrorRate > 1:
+		return errors.New("invalid params: AutoTuneErrorRate must be between 0 and 1")
+	case p.AutoTuneWindow < 0:
+		return errors.New("invalid params: AutoTuneWindow must not be negative")
+	case p.PauseCooldown < 0:
+		return errors.New("invalid params: PauseCooldown must not be negative")
+	case p.OfflineAfterFailures < 0:
+		return errors.New("invalid params: OfflineAfterFailures must not be negative")
+	case p.OfflineProbeInterval < 0:
+		return errors.New("invalid params: OfflineProbeInterval must not be negative")
+	case p.CoalesceWindow < 0:
+		return errors.New("invalid params: CoalesceWindow must not be negative")
+	case p.DialTimeout < 0:
+		return errors.New("invalid params: DialTimeout must not be negative")
+	case p.MaxQueueDepth < 0:
+		return errors.New("invalid params: MaxQueueDepth must not be negative")
+	case p.MaxInFlightBytes < 0:
+		return errors.New("invalid params: MaxInFlightBytes must not be negative")
+	case p.CompressMinSize < 0:
+		return errors.New("invalid params: CompressMinSize must not be negative")
+	case p.OverflowPolicy < OverflowReject || p.OverflowPolicy > OverflowDropNewest:
+		return errors.New("invalid params: unknown OverflowPolicy")
+	case p.PayloadFormat < FormatRaw || p.PayloadFormat > FormatJSON:
+		return errors.New("invalid params: unknown PayloadFormat")
+	case p.OverflowPolicy == OverflowDropOldest && p.MaxQueueDepth == 0:
+		return errors.New("invalid params: OverflowDropOldest requires MaxQueueDepth")
+	case p.MaxRequestsPerConn < 0:
+		return errors.New("invalid params: MaxRequestsPerConn must not be negative")
+	case p.MaxTotalConns < 0:
+		return errors.New("invalid params: MaxTotalConns must not be negative")
+	case p.StreamFrameSize < 0:
+		return errors.New("invalid params: StreamFrameSize must not be negative")
+	case p.MaxMessageSize < 0:
+		return errors.New("invalid params: MaxMessageSize must not be negative")
+	case p.Partitions < 0:
+		return errors.New("invalid params: Partitions must not be negative")
+	case p.Method != "" && !isSupportedMethod(p.Method):
+		return fmt.Errorf("invalid params: unsupported Method %q", p.Method)
+	case p.RequireErrorHandler && p.ErrorHandler == nil:
+		return errors.New("invalid params: ErrorHandler is required")
+	}
+	return nil
+}
+
+// isSupportedMethod reports if requests can be sent using the HTTP method.
+func isSupportedMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
 }
 
 // create creates new client instance. It also used for testing purposes to replace Transport.
 func create(url string, params *ClientParams, transport http.RoundTripper) *Client {
+	// Params are copied, so neither DefaultParams nor caller's params are changed.
+	var effective ClientParams
 	if params == nil {
-		params = DefaultParams
-		params.MaxConcurrentWorkers = calculateOptimalWorkersLimit(transport)
+		effective = *DefaultParams
+		effective.MaxConcurrentWorkers = calculateOptimalWorkersLimit(transport)
+	} else {
+		effective = *params
+	}
+	if effective.MaxConcurrentWorkers == 0 {
+		effective.MaxConcurrentWorkers = 1
 	}
+	params = &effective
 
-	if params.MaxConcurrentWorkers == 0 {
-		params.MaxConcurrentWorkers = 1
+	retryOnStatus := params.RetryOnStatus
+	if len(retryOnStatus) == 0 {
+		retryOnStatus = DefaultRetryOnStatus
+	}
+
+	method := params.Method
+	if !isSupportedMethod(method) {
+		method = http.MethodPost
+	}
+
+	if params.TransportWrapper != nil {
+		transport = params.TransportWrapper(transport)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	n := &Client{
-		url:             url,
-		notifyError:     func(message []byte, err error) {},
-		client:          &http.Client{Transport: transport},
-		ctx:             ctx,
-		cancel:          cancel,
-		workersLimiter:  make(chan struct{}, params.MaxConcurrentWorkers),
-		requestsLimiter: rate.NewLimiter(rate.Every(params.MaxRequestRate), params.MaxRequestsPerRate),
+		notifyError:         func(message []byte, err error) {},
+		mapResult:           func(id string, message []byte, err error) {},
+		broadcast:           func(url string, message []byte, err error) {},
+		complete:            func(message []byte, duration time.Duration, err error) {},
+		keyedResult:         func(key string, statusCode int, err error) {},
+		created:             func(message []byte, location string) {},
+		success:             func(message []byte, resp *http.Response) {},
+		ack:                 func(message []byte, ack interface{}) {},
+		client:              &http.Client{Transport: transport},
+		maxRetries:          params.MaxRetries,
+		retryBackoff:        params.RetryBackoff,
+		retryJitter:         params.RetryJitter,
+		jitterSource:        newJitterSource(params.Rand),
+		maxRetryAfter:       params.MaxRetryAfter,
+		requestTimeout:      params.RequestTimeout,
+		retriesFunc:         params.MaxRetriesFunc,
+		blockWhenFull:       params.BlockWhenFull || params.OverflowPolicy == OverflowBlock,
+		overflowPolicy:      params.OverflowPolicy,
+		encoder:             params.Encoder,
+		signer:              params.Signer,
+		hostHeader:          params.HostHeader,
+		batchHeader:         params.BatchCountHeader,
+		partitions:          params.Partitions,
+		responseValidator:   params.ResponseValidator,
+		accept:              params.Accept,
+		decoders:            newDecoders(params.ResponseDecoders),
+		correlationIDKey:    params.CorrelationIDKey,
+		correlationIDHeader: params.CorrelationIDHeader,
+		sendStartHeader:     params.SendStartHeader,
+		maxMessageSize:      params.MaxMessageSize,
+		shadowURL:           params.ShadowURL,
+		offlineProbeURL:     params.OfflineProbeURL,
+		idempotencyKeys:     params.IdempotencyKeys,
+		streamFrameSize:     params.StreamFrameSize,
+		bodyPrefix:          append([]byte(nil), params.BodyPrefix...),
+		bodySuffix:          append([]byte(nil), params.BodySuffix...),
+		compress:            params.Compress,
+		compressFunc:        params.CompressFunc,
+		compressMinSize:     params.CompressMinSize,
+		contextFunc:         params.ContextFunc,
+		method:              method,
+		failFast:            params.FailFastOnRequestError,
+		closeIdleOnStop:     params.CloseIdleOnStop,
+		headers:             params.Headers.Clone(),
+		authToken:           params.AuthToken,
+		authTokenFunc:       params.AuthTokenFunc,
+		basicAuthUser:       params.BasicAuthUser,
+		basicAuthPassword:   params.BasicAuthPassword,
+		metrics:             &metrics{},
+		retryOnStatus:       make(map[int]struct{}, len(retryOnStatus)),
+		ctx:                 ctx,
+		cancel:              cancel,
+		workersLimiter:      make(chan struct{}, params.MaxConcurrentWorkers),
+		requestsLimiter:     newRequestsLimiter(params),
+	}
+	for _, code := range retryOnStatus {
+		n.retryOnStatus[code] = struct{}{}
+	}
+	if len(params.SuccessStatusCodes) > 0 {
+		n.successStatus = make(map[int]struct{}, len(params.SuccessStatusCodes))
+		for _, code := range params.SuccessStatusCodes {
+			n.successStatus[code] = struct{}{}
+		}
+	}
+	n.url.Store(url)
+	n.params = *params
+	if params.ErrorHandler != nil {
+		n.notifyError = params.ErrorHandler
+	}
+	if n.batchHeader == "" {
+		n.batchHeader = defaultBatchCountHeader
+	}
+	if n.encoder == nil {
+		n.encoder = newBuiltinEncoder(params)
+	}
+	if params.RateKeyFunc != nil {
+		n.rateKeyFunc = params.RateKeyFunc
+		n.keyedLimiters = newKeyedLimiters(func() *rate.Limiter { return newRequestsLimiter(params) })
+	}
+	if params.MinInterval > 0 {
+		n.pacer = newPacer(realClock{}, params.MinInterval)
+	}
+	if params.InitialBurst > 0 {
+		// Tokens of the limiter are spent, so requests after initial burst are paced by the rate from the start.
+		n.initialBurst = int64(params.InitialBurst)
+		n.requestsLimiter.AllowN(time.Now(), params.MaxRequestsPerRate)
+	}
+	if params.AutoTuneErrorRate > 0 {
+		window := params.AutoTuneWindow
+		if window <= 0 {
+			window = defaultAutoTuneWindow
+		}
+		n.tuner = newWorkersTuner(n.workersLimiter, window, params.AutoTuneErrorRate)
+	}
+	if params.PauseErrorRate > 0 && params.PauseWindow > 0 {
+		n.pause = newErrorPause(params.PauseWindow, params.PauseErrorRate, params.PauseCooldown)
+	}
+	if params.OfflineAfterFailures > 0 {
+		interval := params.OfflineProbeInterval
+		if interval <= 0 {
+			interval = defaultOfflineProbeInterval
+		}
+		n.offline = &offlineDetector{
+			threshold: int32(params.OfflineAfterFailures),
+			interval:  interval,
+			probe:     n.probe,
+			ctx:       n.context,
+		}
+	}
+	if params.DeadLetterCapacity > 0 {
+		n.deadLetters = newDeadLetterStore(params.DeadLetterCapacity)
+	}
+	if params.CoalesceConsecutive {
+		n.coalesce = &coalescer{
+			window:  params.CoalesceWindow,
+			hold:    func() { n.workers.Add(1) },
+			release: n.workers.Done,
+			flush:   n.scheduleHeld,
+		}
+	}
+	if params.DedupeCount > 0 {
+		n.dedupe = newDedupeWindow(params.DedupeCount)
+	}
+	if params.SerializePerHost {
+		n.hostLocks = newHostLocks()
+	}
+	if params.SingleFlight {
+		n.flight = &singleflight.Group{}
+	}
+	if params.MaxQueueDepth > 0 {
+		n.queue = newOverflowQueue(params.MaxQueueDepth)
+		n.queueDrained = n.startDrainQueue()
+	}
+	if params.MaxInFlightBytes > 0 {
+		n.inFlight = &byteBudget{limit: params.MaxInFlightBytes}
+		n.sizer = func(message []byte) int { return len(message) }
+		if params.Sizer != nil {
+			n.sizer = params.Sizer
+		}
 	}
 	return n
 }
 
+// newRequestsLimiter creates rate limiter for requests. Zero MaxRequestRate means unlimited rate.
+func newRequestsLimiter(params *ClientParams) *rate.Limiter {
+	if params.MaxRequestRate == 0 {
+		return rate.NewLimiter(rate.Inf, params.MaxRequestsPerRate)
+	}
+	return rate.NewLimiter(rate.Every(params.MaxRequestRate), params.MaxRequestsPerRate)
+}
+
 // calculateOptimalWorkersLimit calculates workers limit based on http.Transport parameters and syscall.Rlimit for more efficient resource usage.
 func calculateOptimalWorkersLimit(transport http.RoundTripper) uint64 {
 	var rLimit syscall.Rlimit
@@ -96,6 +737,25 @@ func calculateOptimalWorkersLimit(transport http.RoundTripper) uint64 {
 	return limit
 }
 
+// URL returns current URL messages are sent to.
+func (c *Client) URL() string {
+	return c.url.Load().(string)
+}
+
+// SetURL changes URL messages are sent to. Already scheduled messages are still sent to the previous URL.
+// It returns an error if URL is not an absolute HTTP(S) URL.
+func (c *Client) SetURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid URL %q: absolute http or https URL expected", rawURL)
+	}
+	c.url.Store(rawURL)
+	return nil
+}
+
 // Notify schedules batch of messages to be sent to event-handling service.
 // Every message will be sent to the server concurrently so call of this function is non-blocking.
 //
@@ -106,73 +766,832 @@ func calculateOptimalWorkersLimit(transport http.RoundTripper) uint64 {
 // Also there are rate limits for requests to the servers. All limits can be adjusted using ClientParams.
 //
 // If workers limit exceeded function will return NotifyErr with TypeWorkersLimitExceeded type.
+// If ClientParams.BlockWhenFull is set it blocks until there is a free worker instead.
+// In this mode large batches are scheduled in waves: worker goroutine is started only when a slot is free,
+// so number of goroutines never exceeds workers limit regardless of the batch size.
+// NotifyErr.Remaining contains messages which have not been scheduled. Nothing is sent for them, so they
+// can be passed to Notify again when workers become free, e.g. after Wait.
 // If notifier has been stopped using Stop call it will return NotifyErr with TypeContextCanceled type.
+//
+// If deduplication is enabled using ClientParams.DedupeCount repeated messages are dropped, but counted as scheduled.
+// Messages rejected by ClientParams.MaxMessageSize or ClientParams.MaxInFlightBytes are passed to the error handler
+// and not counted.
 func (c *Client) Notify(messages ...[]byte) (int, error) {
-	if err := c.ctx.Err(); err != nil {
+	return c.NotifyTo(c.URL(), messages...)
+}
+
+// NotifyTo works the same way as Notify, but sends messages to the provided url instead of the one Client configured with.
+// It can be used to route messages to different endpoints using single Client and its limits.
+func (c *Client) NotifyTo(url string, messages ...[]byte) (int, error) {
+	if c.coalesce != nil {
+		n, err := c.schedule(c.coalesce.add(url, messages))
+		if err != nil {
+			return n, err
+		}
+		return len(messages), nil
+	}
+	return c.schedule(messageTasks(url, messages))
+}
+
+// NotifyAll works the same way as Notify, but it doesn't stop on the first message which can't be scheduled.
+// Every message is tried in turn, so following messages are scheduled if workers become free in the meantime.
+// If some messages have not been scheduled it returns ScheduleErr with error of every such message.
+// It returns other errors as is if Client doesn't accept messages anymore. Messages are never coalesced.
+func (c *Client) NotifyAll(messages ...[]byte) (int, error) {
+	var n int
+	var errs []*NotifyErr
+	for _, t := range messageTasks(c.URL(), messages) {
+		i, err := c.schedule([]task{t})
+		n += i
+		if err == nil {
+			continue
+		}
+		var notifyErr *NotifyErr
+		if !errors.As(err, &notifyErr) {
+			return n, err
+		}
+		if notifyErr.Queued > 0 {
+			n += notifyErr.Queued
+			continue
+		}
+		errs = append(errs, notifyErr)
+	}
+	if len(errs) > 0 {
+		return n, &ScheduleErr{Errors: errs}
+	}
+	return n, nil
+}
+
+// NotifyContext works the same way as Notify, but messages are sent using context derived from ctx.
+// Canceling ctx cancels only these messages, and values of ctx (e.g. correlation ID) are available for the requests.
+// Messages sent using NotifyContext are never coalesced.
+func (c *Client) NotifyContext(ctx context.Context, messages ...[]byte) (int, error) {
+	tasks := messageTasks(c.URL(), messages)
+	batchCtx, finish := c.batchContext(ctx, len(tasks))
+	for i := range tasks {
+		tasks[i].ctx = batchCtx
+		tasks[i].done = func(error) { finish() }
+	}
+	return c.schedule(tasks)
+}
+
+// NotifyMap works the same way as Notify, but every message is associated with caller's ID (map key).
+// The result of every message is passed together with its ID to the handler set by OnMapResult,
+// including messages which have not been scheduled. Messages are scheduled in order of IDs.
+func (c *Client) NotifyMap(m map[string][]byte) (int, error) {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	tasks := make([]task, len(ids))
+	for i, id := range ids {
+		id, msg := id, m[id]
+		tasks[i] = task{url: c.URL(), message: msg, dedupe: true, done: func(err error) {
+			c.mapResult(id, msg, err)
+		}}
+	}
+	return c.schedule(tasks)
+}
+
+// messageTasks creates tasks for messages.
+func messageTasks(url string, messages [][]byte) []task {
+	tasks := make([]task, len(messages))
+	for i, msg := range messages {
+		tasks[i] = task{url: url, message: msg, dedupe: true}
+	}
+	return tasks
+}
+
+// NotifyReaders works the same way as Notify, but streams every reader as a request body
+// without reading it into memory first.
+//
+// Because reader can't be read twice such messages are never retried.
+// Encoding is not applied to them, Signer receives nil body and error handler receives nil message.
+// If some readers have not been scheduled NotifyErr.Remaining is empty, use returned number to find them.
+func (c *Client) NotifyReaders(readers ...io.Reader) (int, error) {
+	tasks := make([]task, len(readers))
+	for i, r := range readers {
+		tasks[i] = task{url: c.URL(), reader: r}
+	}
+	return c.schedule(tasks)
+}
+
+// task is a single message scheduled for sending.
+type task struct {
+	// ctx is used for sending instead of Client context if it is set. It must be derived from Client context.
+	ctx     context.Context
+	url     string
+	message []byte
+	// reader is used as request body instead of message if it is set.
+	reader io.Reader
+	// dedupe enables deduplication of the message if it is configured.
+	dedupe bool
+	// skipDeadLetter disables dead-lettering of the message if it fails.
+	skipDeadLetter bool
+	// done is called exactly once with the result of sending if it is set.
+	// It's also called with an error if task has not been scheduled.
+	done func(err error)
+	// headers are set to the request over Client headers.
+	headers http.Header
+	// idempotencyKey is sent in Idempotency-Key header if it is set.
+	idempotencyKey string
+	// repeatCount is a number of identical messages merged into the task.
+	repeatCount int
+	// batchCount is a number of messages joined into the message by NotifyBatch.
+	batchCount int
+	// rateWaited is set if the first request has already been allowed by rate limiter.
+	rateWaited bool
+}
+
+// count returns number of messages represented by the task.
+func (t task) count() int {
+	if t.repeatCount > 1 {
+		return t.repeatCount
+	}
+	return 1
+}
+
+// schedule starts worker for every task respecting workers limit.
+func (c *Client) schedule(tasks []task) (int, error) {
+	if err := c.acceptErr(); err != nil {
 		var i int
-		for _, msg := range messages {
+		for _, t := range tasks {
 			e := &NotifyErr{
 				Type:    TypeContextCanceled,
 				Message: "Client context canceled",
 				Err:     err,
 			}
-			c.notifyError(msg, e)
-			i++
+			if errors.Is(err, errDraining) {
+				e.Type, e.Message = TypeDraining, "Client is draining"
+			}
+			c.notifyError(t.message, e)
+			if t.done != nil {
+				t.done(e)
+			}
+			c.metrics.recordRejected(t.count())
+			i += t.count()
+		}
+		if errors.Is(err, errDraining) {
+			return 0, &NotifyErr{
+				Type:      TypeDraining,
+				Message:   "Client is draining",
+				Err:       err,
+				Remaining: remainingMessages(tasks),
+			}
 		}
 		return i, err
 	}
 
 	var i int
-	for _, nextMsg := range messages {
+	tasks = c.reserveBytes(tasks)
+	for j, t := range tasks {
+		if err := c.checkTask(t); err != nil {
+			c.metrics.recordRejected(t.count())
+			c.notifyError(t.message, err)
+			if t.done != nil {
+				t.done(err)
+			}
+			continue
+		}
+		if err := c.acquireWorker(c.taskContext(t)); err != nil {
+			if err.Type == TypeWorkersLimitExceeded && c.overflowPolicy != OverflowReject {
+				return i + c.drop(tasks[j:]), nil
+			}
+			err.Remaining = remainingMessages(tasks[j:])
+			queueing := err.Type == TypeWorkersLimitExceeded && c.queue != nil
+			for _, rest := range tasks[j:] {
+				queueing = queueing && rest.reader == nil && c.queue.enqueue(rest, func() { c.workers.Add(1) })
+				if queueing {
+					err.Queued += rest.count()
+					continue
+				}
+				c.metrics.recordRejected(rest.count())
+				if rest.done != nil {
+					rest.done(err)
+				}
+			}
+			return i, err
+		}
+		if c.deferRate(&t) {
+			i += t.count()
+			continue
+		}
+		c.startWorker(t)
+		i += t.count()
+	}
+
+	return i, nil
+}
+
+// deferRate moves the task to the overflow queue if rate limiter doesn't allow to send it right now,
+// so worker slot is not held while waiting for the rate. Queued tasks wait for the rate before acquiring
+// a worker, see drainQueue. Without overflow queue the slot is the only bound of pending messages,
+// so worker waits for the rate holding it. It reports whether the task has been queued and the slot released.
+func (c *Client) deferRate(t *task) bool {
+	if c.queue == nil || t.reader != nil {
+		return false
+	}
+	if c.allowRate(t.message) {
+		t.rateWaited = true
+		return false
+	}
+	if !c.queue.enqueue(*t, func() { c.workers.Add(1) }) {
+		return false
+	}
+	c.releaseWorker()
+	return true
+}
+
+// startWorker starts worker for the task. Worker slot must be already acquired.
+func (c *Client) startWorker(t task) {
+	if t.dedupe && c.dedupe != nil && !c.dedupe.add(t.message) {
+		c.releaseWorker()
+		if t.done != nil {
+			t.done(nil)
+		}
+		return
+	}
+	atomic.AddUint64(&c.metrics.scheduled, 1)
+	c.workers.Add(1)
+	go c.worker(t)
+}
+
+// checkTask checks that message of the task can be sent.
+func (c *Client) checkTask(t task) error {
+	if t.reader == nil && c.maxMessageSize > 0 && len(t.message) > c.maxMessageSize {
+		return &NotifyErr{
+			Type:    TypeInvalidMessage,
+			Message: "Message is too large",
+			Err:     fmt.Errorf("message size %d exceeds limit %d", len(t.message), c.maxMessageSize),
+		}
+	}
+	return nil
+}
+
+// Validate checks which messages would be rejected by Notify without sending them.
+// It returns slice aligned with messages where every item is an error or nil if message would be accepted.
+func (c *Client) Validate(messages ...[]byte) []error {
+	errs := make([]error, len(messages))
+	for i, msg := range messages {
+		if err := c.checkTask(task{url: c.URL(), message: msg}); err != nil {
+			errs[i] = err
+			continue
+		}
+		if _, err := http.NewRequest(c.method, c.URL(), nil); err != nil {
+			errs[i] = &NotifyErr{
+				Type:    TypeSendError,
+				Message: msgSendErrorRequest,
+				Err:     err,
+			}
+		}
+	}
+	return errs
+}
+
+// scheduleHeld schedules task which has been held by coalescer.
+// There is no caller to return an error to, so it's passed to the error handler unless schedule
+// has already reported it to the handler, like TypeDraining error, or the task has been queued.
+func (c *Client) scheduleHeld(t task) {
+	if _, err := c.schedule([]task{t}); err != nil {
+		if nErr, ok := err.(*NotifyErr); ok && nErr.Type != TypeDraining && nErr.Queued == 0 {
+			c.notifyError(t.message, nErr)
+		}
+	}
+}
+
+// remainingMessages returns messages of tasks. Tasks with readers are skipped.
+func remainingMessages(tasks []task) [][]byte {
+	var messages [][]byte
+	for _, t := range tasks {
+		if t.reader != nil {
+			continue
+		}
+		for i := 0; i < t.count(); i++ {
+			messages = append(messages, t.message)
+		}
+	}
+	return messages
+}
+
+// taskContext returns context of the task or Client context if task doesn't have its own.
+func (c *Client) taskContext(t task) context.Context {
+	if t.ctx != nil {
+		return t.ctx
+	}
+	return c.context()
+}
+
+// context returns Client context. It is canceled when Client is stopped.
+func (c *Client) context() context.Context {
+	c.ctxMu.RLock()
+	defer c.ctxMu.RUnlock()
+	return c.ctx
+}
+
+// acquireWorker takes a slot in workers limiter.
+// If there are no free slots it returns TypeWorkersLimitExceeded error or waits for a slot in blocking mode.
+// While waiting it returns TypeContextCanceled error as soon as ctx is done.
+func (c *Client) acquireWorker(ctx context.Context) *NotifyErr {
+	if !c.blockWhenFull {
 		select {
 		case c.workersLimiter <- struct{}{}:
-			c.workers.Add(1)
-			go c.worker(nextMsg)
+			return nil
 		default:
-			return i, &NotifyErr{
+			return &NotifyErr{
 				Type:    TypeWorkersLimitExceeded,
 				Message: "Workers limit exceeded",
 				Err:     nil,
 			}
 		}
-		i++
 	}
+	return c.waitWorker(ctx)
+}
 
-	return i, nil
+// waitWorker waits for a slot in workers limiter regardless of blocking mode.
+// It returns TypeContextCanceled error as soon as ctx is done.
+func (c *Client) waitWorker(ctx context.Context) *NotifyErr {
+	select {
+	case c.workersLimiter <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return &NotifyErr{
+			Type:    TypeContextCanceled,
+			Message: "Client context canceled",
+			Err:     ctx.Err(),
+		}
+	}
 }
 
 // worker handles single message.
-func (c *Client) worker(message []byte) {
+func (c *Client) worker(t task) {
 	defer c.workers.Done()
-	defer func() { <-c.workersLimiter }()
-	req, err := http.NewRequestWithContext(c.ctx, http.MethodPost, c.url, bytes.NewReader(message))
+	defer c.releaseWorker()
+	if c.shadowURL != "" && t.reader == nil {
+		c.workers.Add(1)
+		go c.sendShadow(t)
+	}
+	if c.idempotencyKeys && t.reader == nil {
+		t.idempotencyKey = newIdempotencyKey()
+	}
+	c.markSent()
+	start := time.Now()
+	statusCode, err := c.sendShared(t)
+	duration := time.Since(start)
+	c.complete(t.message, duration, err)
+	if s := c.statsdEmitter(); s != nil {
+		s.emit(err != nil, duration)
+	}
+	if t.idempotencyKey != "" {
+		c.keyedResult(t.idempotencyKey, statusCode, err)
+	}
+	if c.pause != nil {
+		c.pause.record(err != nil)
+	}
+	if c.tuner != nil {
+		c.tuner.record(err != nil)
+	}
+	if t.done != nil {
+		defer t.done(err)
+	}
 	if err != nil {
-		e := &NotifyErr{
-			Type:    TypeSendError,
-			Message: msgSendErrorRequest,
-			Err:     err,
+		c.metrics.recordFailed(err)
+		if f := c.deadLettersFile(); f != nil && t.reader == nil && !t.skipDeadLetter {
+			f.write(DeadLetter{Message: t.message, Err: err})
+		}
+		if c.deadLetters != nil && t.reader == nil && !t.skipDeadLetter {
+			c.deadLetters.add(DeadLetter{Message: t.message, Err: err})
 		}
-		c.notifyError(message, e)
+		c.notifyError(t.message, err)
 		return
 	}
-	if err := c.requestsLimiter.Wait(c.ctx); err != nil {
-		e := &NotifyErr{
-			Type:    TypeSendError,
-			Message: msgSendErrorRateLimiter,
-			Err:     err,
+	atomic.AddUint64(&c.metrics.succeeded, 1)
+}
+
+// send sends single message and returns NotifyErr if message has not been delivered.
+// If server responds with one of retryOnStatus codes or request fails with a connection error
+// the message is sent again up to maxRetries times waiting retryBackoff doubled on every retry.
+func (c *Client) send(t task) (statusCode int, err error) {
+	ctx, cancel := c.requestContext(c.taskContext(t), t.message)
+	defer cancel()
+	parent := ctx
+	if c.requestTimeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, c.requestTimeout)
+		defer cancelTimeout()
+	}
+	var attempts int
+	defer func() {
+		if err != nil && parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// Message has timed out, so whatever step failed it's reported as timeout.
+			err = &NotifyErr{
+				Type:    TypeTimeout,
+				Message: msgSendErrorTimeout,
+				Err:     fmt.Errorf("%w after %v", context.DeadlineExceeded, c.requestTimeout),
+			}
+		}
+		if notifyErr, ok := err.(*NotifyErr); ok {
+			notifyErr.Attempts = attempts
+		}
+	}()
+	var body []byte
+	var contentType, contentEncoding string
+	retries := 0
+	url := t.url
+	if t.reader == nil {
+		if body, contentType, err = c.encoder.Encode(t.message); err != nil {
+			return 0, &NotifyErr{
+				Type:    TypeSendError,
+				Message: msgSendErrorRequest,
+				Err:     err,
+			}
+		}
+		body = c.frameBody(body)
+		if c.shouldCompress(t.message, body) {
+			if body, err = gzipBody(body); err != nil {
+				return 0, &NotifyErr{
+					Type:    TypeSendError,
+					Message: msgSendErrorRequest,
+					Err:     err,
+				}
+			}
+			contentEncoding = gzipEncoding
+		}
+		retries = c.maxRetries
+		if c.retriesFunc != nil {
+			retries = c.retriesFunc(t.message)
+		}
+		if c.partitions > 0 {
+			url = partitionURL(url, partition(t.message, c.partitions))
 		}
-		c.notifyError(message, e)
-		return
 	}
-	if _, err := c.client.Do(req); err != nil { //nolint: bodyclose
-		e := &NotifyErr{
+	token := c.bearerToken()
+	sender, err := c.senders.get(url)
+	if err != nil {
+		return 0, &NotifyErr{
 			Type:    TypeSendError,
 			Message: msgSendErrorClient,
 			Err:     err,
 		}
-		c.notifyError(message, e)
-		return
 	}
+	if sender != nil {
+		attempts = 1
+		return 0, c.sendWith(ctx, sender, t, body)
+	}
+	rateWaited := t.rateWaited
+	for attempt := 0; ; attempt++ {
+		reqBody := t.reader
+		if reqBody == nil {
+			reqBody = bytes.NewReader(body)
+		} else if c.streamFrameSize > 0 {
+			reqBody = newFrameEncoder(reqBody, c.streamFrameSize)
+		}
+		req, err := http.NewRequestWithContext(ctx, c.method, url, reqBody)
+		if err != nil {
+			if c.failFast {
+				c.Stop()
+			}
+			return 0, &NotifyErr{
+				Type:    TypeSendError,
+				Message: msgSendErrorRequest,
+				Err:     err,
+			}
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if contentEncoding != "" {
+			req.Header.Set("Content-Encoding", contentEncoding)
+		}
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+		setHeaders(req, c.headers)
+		setHeaders(req, t.headers)
+		c.authorize(req, token)
+		if t.reader != nil && c.streamFrameSize > 0 {
+			req.Header.Set(framingHeader, framingLengthValue)
+		}
+		if c.hostHeader != "" {
+			req.Host = c.hostHeader
+		}
+		if t.idempotencyKey != "" {
+			req.Header.Set(idempotencyKeyHeader, t.idempotencyKey)
+		}
+		if t.repeatCount > 0 {
+			req.Header.Set(repeatCountHeader, strconv.Itoa(t.repeatCount))
+		}
+		if t.batchCount > 0 {
+			req.Header.Set(c.batchHeader, strconv.Itoa(t.batchCount))
+		}
+		if c.correlationIDKey != nil && c.correlationIDHeader != "" {
+			if id := ctx.Value(c.correlationIDKey); id != nil {
+				req.Header.Set(c.correlationIDHeader, fmt.Sprint(id))
+			}
+		}
+		if err := c.waitGates(ctx); err != nil {
+			return 0, &NotifyErr{
+				Type:    TypeContextCanceled,
+				Message: "Client context canceled",
+				Err:     err,
+			}
+		}
+		if rateWaited {
+			rateWaited = false
+		} else if err := c.waitRate(ctx, t.message); err != nil {
+			return 0, c.rateWaitErr(ctx, err)
+		}
+		if c.pacer != nil {
+			if err := c.pacer.wait(ctx); err != nil {
+				return 0, c.rateWaitErr(ctx, err)
+			}
+		}
+		// Send start header is set before signing, so signers covering all headers sign it too.
+		if c.sendStartHeader {
+			req.Header.Set(sendStartHeader, time.Now().UTC().Format(time.RFC3339Nano))
+		}
+		if c.signer != nil {
+			if err := c.signer.Sign(req, body); err != nil {
+				return 0, &NotifyErr{
+					Type:    TypeSendError,
+					Message: msgSendErrorSign,
+					Err:     err,
+				}
+			}
+		}
+		unlock, err := c.lockHost(ctx, req.URL.Host)
+		if err != nil {
+			return 0, &NotifyErr{
+				Type:    TypeContextCanceled,
+				Message: "Client context canceled",
+				Err:     err,
+			}
+		}
+		attempts++
+		resp, err := c.client.Do(req)
+		if err != nil {
+			unlock()
+			if c.offline != nil && t.reader == nil && ctx.Err() == nil && c.offline.failed() {
+				// Server is unreachable, the message waits in waitGates until it's back online.
+				attempt--
+				continue
+			}
+			if ctx.Err() != nil {
+				return 0, &NotifyErr{
+					Type:    TypeContextCanceled,
+					Message: "Client context canceled",
+					Err:     err,
+				}
+			}
+			msg := msgSendErrorClient
+			var dnsErr *net.DNSError
+			if errors.As(err, &dnsErr) {
+				// DNS problems usually affect all endpoints, so they are reported separately.
+				msg = msgSendErrorDNS
+			}
+			notifyErr := &NotifyErr{
+				Type:    TypeSendError,
+				Message: msg,
+				Err:     err,
+			}
+			if attempt < retries && c.shouldRetry(t.message, notifyErr, true) {
+				if err := c.backoff(ctx, c.backoffDelay(attempt)); err != nil {
+					return 0, err
+				}
+				atomic.AddUint64(&c.metrics.retried, 1)
+				continue
+			}
+			return 0, notifyErr
+		}
+		if c.offline != nil {
+			c.offline.succeeded()
+		}
+		canRetry := attempt < retries
+		retry, ack, err := c.checkResponse(resp, canRetry && c.retryDecider == nil)
+		unlock()
+		if err != nil && canRetry {
+			retry = c.shouldRetry(t.message, err, false)
+		}
+		if !retry {
+			if err == nil {
+				if resp.StatusCode == http.StatusCreated {
+					c.created(t.message, resp.Header.Get("Location"))
+				}
+				if ack != nil {
+					c.ack(t.message, ack)
+				}
+				resp.Body = http.NoBody
+				c.success(t.message, resp)
+			}
+			return resp.StatusCode, err
+		}
+		delay, ok := retryAfter(resp, time.Now())
+		if !ok {
+			delay = c.backoffDelay(attempt)
+		} else if c.maxRetryAfter > 0 && delay > c.maxRetryAfter {
+			return resp.StatusCode, &NotifyErr{
+				Type:       TypeSendError,
+				Message:    msgSendErrorStatus,
+				Err:        fmt.Errorf("retry after %s exceeds max retry after %s", delay, c.maxRetryAfter),
+				StatusCode: resp.StatusCode,
+			}
+		}
+		if err := c.backoff(ctx, delay); err != nil {
+			return 0, err
+		}
+		atomic.AddUint64(&c.metrics.retried, 1)
+	}
+}
+
+// shouldRetry reports if failed attempt to send the message should be retried.
+// Decision of the handler set by OnErrorDecide overrides the default one.
+func (c *Client) shouldRetry(message []byte, err error, def bool) bool {
+	if c.retryDecider != nil {
+		return c.retryDecider(message, err)
+	}
+	return def
+}
+
+// backoffDelay returns retryBackoff * 2^attempt shortened by jitter.
+func (c *Client) backoffDelay(attempt int) time.Duration {
+	return c.jitter(c.retryBackoff << uint(attempt))
+}
+
+// backoff waits delay before the next attempt.
+// It returns NotifyErr if ctx is done while waiting.
+func (c *Client) backoff(ctx context.Context, delay time.Duration) *NotifyErr {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return &NotifyErr{
+			Type:    TypeContextCanceled,
+			Message: "Client context canceled",
+			Err:     ctx.Err(),
+		}
+	}
+}
+
+// waitRate blocks until request is allowed by rate limiter. Requests of initial burst are allowed immediately.
+func (c *Client) waitRate(ctx context.Context, message []byte) error {
+	if atomic.LoadInt64(&c.initialBurst) > 0 && atomic.AddInt64(&c.initialBurst, -1) >= 0 {
+		return nil
+	}
+	limiter, release := c.limiter(message)
+	defer release()
+	return limiter.Wait(ctx)
+}
+
+// rateWaitErr returns error of waiting for rate limiter or pacer. Waiting interrupted by canceled context
+// of the message, e.g. by NotifyContext or Batch.Cancel, is reported as TypeContextCanceled error.
+// Waiting interrupted by Stop is reported as TypeSendError error.
+func (c *Client) rateWaitErr(ctx context.Context, err error) *NotifyErr {
+	if ctx.Err() != nil && c.context().Err() == nil {
+		return &NotifyErr{
+			Type:    TypeContextCanceled,
+			Message: "Client context canceled",
+			Err:     ctx.Err(),
+		}
+	}
+	return &NotifyErr{
+		Type:    TypeSendError,
+		Message: msgSendErrorRateLimiter,
+		Err:     err,
+	}
+}
+
+// allowRate reports whether request is allowed by rate limiter right now. Unlike waitRate it never blocks.
+func (c *Client) allowRate(message []byte) bool {
+	if atomic.LoadInt64(&c.initialBurst) > 0 && atomic.AddInt64(&c.initialBurst, -1) >= 0 {
+		return true
+	}
+	limiter, release := c.limiter(message)
+	defer release()
+	return limiter.Allow()
+}
+
+// checkResponse reads and closes response body and decides if message has been delivered.
+// It returns retry flag if message should be sent again and canRetry is set,
+// and acknowledgement decoded by ResponseDecoders if message has been delivered.
+func (c *Client) checkResponse(resp *http.Response, canRetry bool) (bool, interface{}, error) {
+	defer closeResponse(resp)
+
+	var body []byte
+	if c.responseValidator != nil || len(c.decoders) > 0 {
+		var err error
+		body, err = ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize))
+		if err != nil {
+			return false, nil, &NotifyErr{
+				Type:       TypeSendError,
+				Message:    msgSendErrorResponse,
+				Err:        err,
+				StatusCode: resp.StatusCode,
+			}
+		}
+	}
+
+	_, retryable := c.retryOnStatus[resp.StatusCode]
+	if c.responseValidator != nil {
+		if err := c.responseValidator(resp.StatusCode, body); err != nil {
+			if retryable && canRetry {
+				return true, nil, nil
+			}
+			return false, nil, &NotifyErr{
+				Type:       TypeSendError,
+				Message:    msgSendErrorResponse,
+				Err:        err,
+				StatusCode: resp.StatusCode,
+			}
+		}
+		ack, err := c.decodeResponse(resp, body)
+		return false, ack, err
+	}
+
+	if retryable && canRetry {
+		return true, nil, nil
+	}
+	if !retryable && c.isSuccessStatus(resp.StatusCode) {
+		ack, err := c.decodeResponse(resp, body)
+		return false, ack, err
+	}
+	return false, nil, &NotifyErr{
+		Type:       TypeSendError,
+		Message:    msgSendErrorStatus,
+		Err:        fmt.Errorf("unexpected response status %d", resp.StatusCode),
+		StatusCode: resp.StatusCode,
+	}
+}
+
+// isSuccessStatus reports if response status means the message has been delivered.
+func (c *Client) isSuccessStatus(code int) bool {
+	if c.successStatus != nil {
+		_, ok := c.successStatus[code]
+		return ok
+	}
+	return code >= http.StatusOK && code < http.StatusMultipleChoices
+}
+
+// bearerToken returns token which should be sent with the message or empty string if it's not set.
+func (c *Client) bearerToken() string {
+	if c.authTokenFunc != nil {
+		return c.authTokenFunc()
+	}
+	return c.authToken
+}
+
+// authorize sets Authorization header using bearer token or basic auth credentials
+// unless the header is already set explicitly by Headers.
+func (c *Client) authorize(req *http.Request, token string) {
+	switch {
+	case req.Header.Get("Authorization") != "":
+	case token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+	case c.basicAuthUser != "" && c.basicAuthPassword != "":
+		req.SetBasicAuth(c.basicAuthUser, c.basicAuthPassword)
+	}
+}
+
+// setHeaders sets values of headers to the request replacing existing ones.
+func setHeaders(req *http.Request, headers http.Header) {
+	for key, values := range headers {
+		req.Header.Del(key)
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+}
+
+// closeResponse drains up to maxResponseBodySize of unread response body and closes it,
+// so the connection can be reused by the transport.
+func closeResponse(resp *http.Response) {
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxResponseBodySize))
+	resp.Body.Close() //nolint: errcheck, gosec
+}
+
+// waitGates blocks while sending is paused, client is in maintenance or offline.
+func (c *Client) waitGates(ctx context.Context) error {
+	for c.maintenance.isClosed() || c.pause != nil && c.pause.isClosed() || c.offline != nil && c.offline.isClosed() {
+		if err := c.maintenance.wait(ctx); err != nil {
+			return err
+		}
+		if c.pause != nil {
+			if err := c.pause.wait(ctx); err != nil {
+				return err
+			}
+		}
+		if c.offline != nil {
+			if err := c.offline.wait(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 // OnError sets custom error handler which can be used to handle messages that has not been proceed.
@@ -183,9 +1602,151 @@ func (c *Client) OnError(handler func(message []byte, err error)) {
 	}
 }
 
+// OnErrorDecide sets handler which decides if failed attempt to send the message should be retried.
+// It's called for every failed attempt while retries are left, see ClientParams.MaxRetries, and overrides
+// the default decision based on ClientParams.RetryOnStatus. If the message fails finally it's passed to OnError handler.
+func (c *Client) OnErrorDecide(handler func(message []byte, err error) (retry bool)) {
+	c.retryDecider = handler
+}
+
+// OnComplete sets handler which is called when sending of every message is finished.
+// It receives time spent on sending including retries, and error which is nil if message has been delivered.
+func (c *Client) OnComplete(handler func(message []byte, duration time.Duration, err error)) {
+	if handler != nil {
+		c.complete = handler
+	}
+}
+
+// OnCreated sets handler which is called when server responds to the message with 201 Created status.
+// It receives value of Location header which is empty if server hasn't sent it.
+func (c *Client) OnCreated(handler func(message []byte, location string)) {
+	if handler != nil {
+		c.created = handler
+	}
+}
+
+// OnSuccess sets handler which is called for every delivered message.
+// Response body is already read and closed, so handler can inspect only status and headers.
+func (c *Client) OnSuccess(handler func(message []byte, resp *http.Response)) {
+	if handler != nil {
+		c.success = handler
+	}
+}
+
+// OnKeyedResult sets handler which receives result of every message sent with idempotency key.
+// statusCode is a status of the last response or 0 if server hasn't responded. See ClientParams.IdempotencyKeys.
+func (c *Client) OnKeyedResult(handler func(key string, statusCode int, err error)) {
+	if handler != nil {
+		c.keyedResult = handler
+	}
+}
+
+// OnMapResult sets handler which receives result of every message sent using NotifyMap together with its ID.
+// err is nil if message has been delivered.
+func (c *Client) OnMapResult(handler func(id string, message []byte, err error)) {
+	if handler != nil {
+		c.mapResult = handler
+	}
+}
+
+// OnPause sets handler which is called when Client pauses sending because of high error rate.
+// It has effect only if pausing is enabled using ClientParams.PauseErrorRate.
+func (c *Client) OnPause(handler func()) {
+	if handler != nil && c.pause != nil {
+		c.pause.setHandler(&c.pause.onPause, handler)
+	}
+}
+
+// OnResume sets handler which is called when Client resumes sending after pause.
+// It has effect only if pausing is enabled using ClientParams.PauseErrorRate.
+func (c *Client) OnResume(handler func()) {
+	if handler != nil && c.pause != nil {
+		c.pause.setHandler(&c.pause.onResume, handler)
+	}
+}
+
 // Stop cancel scheduled tasks.
 func (c *Client) Stop() {
-	c.cancel()
+	c.ctxMu.RLock()
+	cancel := c.cancel
+	c.ctxMu.RUnlock()
+	cancel()
+	if c.pause != nil {
+		c.pause.stop()
+	}
+}
+
+// Close stops Client, waits for all workers to finish and releases resources:
+// dead letters file is synced to the disk and closed, statsd connection and Senders are closed.
+func (c *Client) Close() error {
+	c.Stop()
+	c.Wait()
+	c.warmDown()
+	var err error
+	if f := c.deadLettersFile(); f != nil {
+		err = f.close()
+	}
+	c.statsdMu.Lock()
+	if c.statsd != nil {
+		_ = c.statsd.conn.Close()
+		c.statsd = nil
+	}
+	c.statsdMu.Unlock()
+	if sErr := c.senders.close(); err == nil {
+		err = sErr
+	}
+	return err
+}
+
+// StopGrace stops accepting new messages and waits up to grace for already scheduled tasks to complete.
+// Tasks which are still running after grace are canceled the same way as Stop does.
+// If all tasks complete within grace and ClientParams.CloseIdleOnStop is set, idle connections are closed.
+func (c *Client) StopGrace(grace time.Duration) {
+	_ = c.StopWithTimeout(grace)
+}
+
+// StopWithTimeout works the same way as StopGrace, but returns an error wrapping context.DeadlineExceeded
+// if tasks have not completed within timeout and have been canceled.
+func (c *Client) StopWithTimeout(timeout time.Duration) error {
+	atomic.StoreInt32(&c.stopping, 1)
+	defer c.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		c.workers.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		c.warmDown()
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("tasks have not completed within %v: %w", timeout, context.DeadlineExceeded)
+	}
+}
+
+// warmDown closes idle connections if ClientParams.CloseIdleOnStop is set.
+// It must be called when there are no in-flight requests, so no request is interrupted.
+func (c *Client) warmDown() {
+	if c.closeIdleOnStop {
+		c.client.CloseIdleConnections()
+	}
+}
+
+// acceptErr returns an error if Client doesn't accept new messages.
+func (c *Client) acceptErr() error {
+	if err := c.context().Err(); err != nil {
+		return err
+	}
+	if atomic.LoadInt32(&c.stopping) == 1 {
+		return context.Canceled
+	}
+	if atomic.LoadInt32(&c.draining) == 1 {
+		return errDraining
+	}
+	return nil
 }
 
 // Wait blocks execution until all already scheduled workers finishes their work.
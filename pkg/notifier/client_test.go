@@ -1,17 +1,25 @@
 package notifier
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
 )
 
 func TestNotifier_Notify(t *testing.T) {
@@ -91,6 +99,28 @@ func TestNotifier_Notify(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("DNS error", func(t *testing.T) {
+		errs := make(chan error, 1)
+		notifier := New("http://unresolvable.invalid/", &ClientParams{
+			MaxConcurrentWorkers: 1,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   1,
+		})
+		notifier.OnError(func(message []byte, err error) {
+			errs <- err
+		})
+		_, err := notifier.Notify([]byte("test message"))
+		notifier.Wait()
+
+		require.NoError(t, err)
+		var nErr *NotifyErr
+		require.True(t, errors.As(<-errs, &nErr))
+		assert.Equal(t, TypeSendError, nErr.Type)
+		assert.Equal(t, msgSendErrorDNS, nErr.Message)
+		var dnsErr *net.DNSError
+		assert.True(t, errors.As(nErr, &dnsErr))
+	})
+
 	t.Run("Limiter error", func(t *testing.T) {
 		testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 			writer.WriteHeader(http.StatusOK)
@@ -110,7 +140,8 @@ func TestNotifier_Notify(t *testing.T) {
 			assert.Equal(t, TypeSendError, nErr.Type)
 		})
 		_, err := notifier.Notify(msg...)
-		time.Sleep(time.Second)
+		// Stop before the next token, so all remaining messages are waiting for the limiter.
+		time.Sleep(500 * time.Millisecond)
 		notifier.Stop()
 		notifier.Wait()
 
@@ -118,6 +149,34 @@ func TestNotifier_Notify(t *testing.T) {
 	})
 }
 
+func TestNotifier_NotifyAll(t *testing.T) {
+	release := make(chan struct{})
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		<-release
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+	})
+	messages := generateTestMessages(50)
+	n, err := notifier.NotifyAll(messages...)
+	close(release)
+	notifier.Wait()
+
+	assert.Equal(t, 2, n)
+	var scheduleErr *ScheduleErr
+	require.True(t, errors.As(err, &scheduleErr))
+	assert.True(t, errors.Is(err, &NotifyErr{Type: TypeWorkersLimitExceeded}))
+	require.Len(t, scheduleErr.Errors, 48)
+	for i, nErr := range scheduleErr.Errors {
+		assert.Equal(t, TypeWorkersLimitExceeded, nErr.Type)
+		assert.Equal(t, [][]byte{messages[i+2]}, nErr.Remaining)
+	}
+}
+
 func TestNotifier_DifferentLimits(t *testing.T) {
 	t.Run("Default", func(t *testing.T) {
 		transport := getTestTransport()
@@ -184,14 +243,1185 @@ func TestNotifier_DifferentLimits(t *testing.T) {
 
 		assert.Error(t, err)
 		assert.NotZero(t, n)
+		var nErr *NotifyErr
+		require.True(t, errors.As(err, &nErr))
+		assert.Equal(t, messages[n:], nErr.Remaining)
+		notifier.Wait()
+	})
+
+	t.Run("Limit exceeded resubmit", func(t *testing.T) {
+		var delivered int32
+		testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&delivered, 1)
+		}))
+		defer testSrv.Close()
+
+		transport := getTestTransport()
+		transport.MaxIdleConnsPerHost = 0
+		transport.MaxIdleConns = 0
+		transport.MaxConnsPerHost = 0
+		notifier := create(testSrv.URL, &ClientParams{MaxConcurrentWorkers: 100}, transport)
+		notifier.OnError(func(message []byte, err error) {
+			t.Errorf("unexpected error: %v", err)
+		})
+
+		remaining := generateTestMessages(1000)
+		for attempt := 0; len(remaining) > 0; attempt++ {
+			require.Less(t, attempt, 100, "messages must be scheduled as workers become free")
+			n, err := notifier.Notify(remaining...)
+			if err == nil {
+				assert.Equal(t, len(remaining), n)
+				break
+			}
+			var nErr *NotifyErr
+			require.True(t, errors.As(err, &nErr))
+			require.Equal(t, TypeWorkersLimitExceeded, nErr.Type)
+			require.Len(t, nErr.Remaining, len(remaining)-n)
+			remaining = nErr.Remaining
+			notifier.Wait()
+		}
+		notifier.Wait()
+
+		assert.Equal(t, int32(1000), atomic.LoadInt32(&delivered))
+	})
+}
+
+func TestNotifier_NotifyReaders(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		mu.Lock()
+		received = append(received, string(body))
+		mu.Unlock()
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	large := strings.Repeat("large message ", 10000)
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	n, err := notifier.NotifyReaders(bytes.NewReader([]byte("small message")), bytes.NewReader([]byte(large)))
+	notifier.Wait()
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.ElementsMatch(t, []string{"small message", large}, received)
+}
+
+func TestNotifier_HostHeader(t *testing.T) {
+	hosts := make(chan string, 1)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		hosts <- request.Host
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+	require.Contains(t, testSrv.URL, "127.0.0.1")
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		HostHeader:           "api.example.com",
+	})
+	_, err := notifier.Notify([]byte("test message"))
+	notifier.Wait()
+
+	require.NoError(t, err)
+	assert.Equal(t, "api.example.com", <-hosts)
+}
+
+func TestNotifier_DialTimeout(t *testing.T) {
+	errs := make(chan error, 1)
+	notifier := New("http://10.255.255.1/", &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		DialTimeout:          100 * time.Millisecond,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		errs <- err
+	})
+
+	start := time.Now()
+	_, err := notifier.Notify([]byte("test message"))
+	require.NoError(t, err)
+	notifier.Wait()
+
+	assert.True(t, time.Since(start) < 2*time.Second, "dial must fail within configured timeout")
+	var nErr *NotifyErr
+	require.True(t, errors.As(<-errs, &nErr))
+	assert.Equal(t, msgSendErrorClient, nErr.Message)
+}
+
+func TestNotifier_NotifyMap(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		if string(body) == "bad" {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 3,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   3,
+	})
+	var mu sync.Mutex
+	results := make(map[string]error)
+	notifier.OnMapResult(func(id string, message []byte, err error) {
+		mu.Lock()
+		results[id] = err
+		mu.Unlock()
+	})
+	n, err := notifier.NotifyMap(map[string][]byte{
+		"id-1": []byte("good"),
+		"id-2": []byte("bad"),
+		"id-3": []byte("good too"),
+	})
+	notifier.Wait()
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	require.Len(t, results, 3)
+	assert.NoError(t, results["id-1"])
+	assert.Error(t, results["id-2"])
+	assert.NoError(t, results["id-3"])
+}
+
+func TestNotifier_SendStartHeader(t *testing.T) {
+	sendStarts := make(chan string, 1)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		sendStarts <- request.Header.Get(sendStartHeader)
+		time.Sleep(10 * time.Millisecond)
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		SendStartHeader:      true,
+	})
+	durations := make(chan time.Duration, 1)
+	notifier.OnComplete(func(message []byte, duration time.Duration, err error) {
+		assert.NoError(t, err)
+		durations <- duration
+	})
+	before := time.Now()
+	_, err := notifier.Notify([]byte("test message"))
+	require.NoError(t, err)
+	notifier.Wait()
+
+	sentAt, err := time.Parse(time.RFC3339Nano, <-sendStarts)
+	require.NoError(t, err)
+	assert.False(t, sentAt.Before(before.Truncate(time.Millisecond)))
+	assert.True(t, <-durations >= 10*time.Millisecond)
+}
+
+// headerSigner records the value of the header at the moment of signing.
+type headerSigner struct {
+	header string
+	signed chan string
+}
+
+func (s headerSigner) Sign(req *http.Request, body []byte) error {
+	s.signed <- req.Header.Get(s.header)
+	return nil
+}
+
+func TestNotifier_SendStartHeaderSigned(t *testing.T) {
+	received := make(chan string, 1)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		received <- request.Header.Get(sendStartHeader)
+	}))
+	defer testSrv.Close()
+
+	signer := headerSigner{header: sendStartHeader, signed: make(chan string, 1)}
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		SendStartHeader:      true,
+		Signer:               signer,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	_, err := notifier.Notify([]byte("test message"))
+	require.NoError(t, err)
+	notifier.Wait()
+
+	signed := <-signer.signed
+	assert.NotEmpty(t, signed, "header must be set before signing")
+	assert.Equal(t, signed, <-received)
+}
+
+func TestNotifier_StopGrace(t *testing.T) {
+	params := &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+	}
+
+	t.Run("Requests complete within grace", func(t *testing.T) {
+		var received int32
+		testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+			atomic.AddInt32(&received, 1)
+			writer.WriteHeader(http.StatusOK)
+		}))
+		defer testSrv.Close()
+
+		notifier := New(testSrv.URL, params)
+		var failed int32
+		notifier.OnError(func(message []byte, err error) {
+			atomic.AddInt32(&failed, 1)
+		})
+		_, err := notifier.Notify(generateTestMessages(2)...)
+		require.NoError(t, err)
+		notifier.StopGrace(time.Second)
+
+		assert.Equal(t, int32(2), atomic.LoadInt32(&received))
+		assert.Equal(t, int32(0), atomic.LoadInt32(&failed))
+		_, err = notifier.Notify([]byte("late"))
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+
+	t.Run("Requests exceeding grace are canceled", func(t *testing.T) {
+		testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			_, _ = ioutil.ReadAll(request.Body)
+			select {
+			case <-request.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}))
+		defer testSrv.Close()
+
+		notifier := New(testSrv.URL, params)
+		var failed int32
+		notifier.OnError(func(message []byte, err error) {
+			atomic.AddInt32(&failed, 1)
+		})
+		_, err := notifier.Notify(generateTestMessages(2)...)
+		require.NoError(t, err)
+
+		start := time.Now()
+		notifier.StopGrace(50 * time.Millisecond)
+		notifier.Wait()
+
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&failed))
+	})
+
+	t.Run("Idle connections are closed", func(t *testing.T) {
+		stopGrace := func(t *testing.T, closeIdle bool) (int32, int32) {
+			var opened, closed int32
+			testSrv := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				_, _ = ioutil.ReadAll(request.Body)
+				time.Sleep(20 * time.Millisecond)
+			}))
+			testSrv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+				switch state {
+				case http.StateNew:
+					atomic.AddInt32(&opened, 1)
+				case http.StateClosed:
+					atomic.AddInt32(&closed, 1)
+				}
+			}
+			testSrv.Start()
+			defer testSrv.Close()
+
+			notifier := create(testSrv.URL, &ClientParams{
+				MaxConcurrentWorkers: 2,
+				MaxRequestRate:       time.Millisecond,
+				MaxRequestsPerRate:   2,
+				CloseIdleOnStop:      closeIdle,
+			}, getTestTransport())
+			notifier.OnError(func(message []byte, err error) {
+				t.Errorf("unexpected error: %v", err)
+			})
+			_, err := notifier.Notify(generateTestMessages(2)...)
+			require.NoError(t, err)
+			notifier.StopGrace(time.Second)
+
+			// Server notices closed connections asynchronously.
+			time.Sleep(100 * time.Millisecond)
+			return atomic.LoadInt32(&opened), atomic.LoadInt32(&closed)
+		}
+
+		opened, closed := stopGrace(t, true)
+		assert.NotZero(t, opened)
+		assert.Equal(t, opened, closed, "all connections must be closed by the client")
+
+		opened, closed = stopGrace(t, false)
+		assert.NotZero(t, opened)
+		assert.Zero(t, closed, "idle connections are kept without CloseIdleOnStop")
+	})
+}
+
+func TestNotifier_StopWithTimeout(t *testing.T) {
+	params := &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+	}
+
+	t.Run("Drained in time", func(t *testing.T) {
+		var received int32
+		testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&received, 1)
+		}))
+		defer testSrv.Close()
+
+		notifier := New(testSrv.URL, params)
+		_, err := notifier.Notify(generateTestMessages(2)...)
+		require.NoError(t, err)
+
+		require.NoError(t, notifier.StopWithTimeout(time.Second))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&received))
+		_, err = notifier.Notify([]byte("late"))
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+
+	t.Run("Timed out", func(t *testing.T) {
+		testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			_, _ = ioutil.ReadAll(request.Body)
+			<-request.Context().Done()
+		}))
+		defer testSrv.Close()
+
+		notifier := New(testSrv.URL, params)
+		var failed int32
+		notifier.OnError(func(message []byte, err error) {
+			atomic.AddInt32(&failed, 1)
+		})
+		_, err := notifier.Notify(generateTestMessages(2)...)
+		require.NoError(t, err)
+
+		start := time.Now()
+		err = notifier.StopWithTimeout(50 * time.Millisecond)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
 		notifier.Wait()
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&failed), "running tasks must be canceled")
 	})
 }
 
+func TestNotifier_SetURL(t *testing.T) {
+	release := make(chan struct{})
+	var oldReceived, newReceived int32
+	oldSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		<-release
+		atomic.AddInt32(&oldReceived, 1)
+	}))
+	defer oldSrv.Close()
+	newSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&newReceived, 1)
+	}))
+	defer newSrv.Close()
+
+	notifier := New(oldSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   4,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	_, err := notifier.Notify(generateTestMessages(2)...)
+	require.NoError(t, err)
+
+	assert.Error(t, notifier.SetURL("%"))
+	assert.Error(t, notifier.SetURL("/relative"))
+	assert.Error(t, notifier.SetURL("ftp://localhost"))
+	require.NoError(t, notifier.SetURL(newSrv.URL))
+	assert.Equal(t, newSrv.URL, notifier.URL())
+
+	_, err = notifier.Notify(generateTestMessages(2)...)
+	require.NoError(t, err)
+	close(release)
+	notifier.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&oldReceived))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&newReceived))
+}
+
+func TestNotifier_InitialBurst(t *testing.T) {
+	var mu sync.Mutex
+	var received []time.Time
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mu.Lock()
+		received = append(received, time.Now())
+		mu.Unlock()
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 5,
+		MaxRequestRate:       100 * time.Millisecond,
+		MaxRequestsPerRate:   1,
+		InitialBurst:         3,
+	})
+	start := time.Now()
+	_, err := notifier.Notify(generateTestMessages(5)...)
+	require.NoError(t, err)
+	notifier.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 5)
+	for i, at := range received[:3] {
+		assert.Less(t, int64(at.Sub(start)), int64(50*time.Millisecond), "message %d should be sent immediately", i)
+	}
+	assert.GreaterOrEqual(t, int64(received[3].Sub(start)), int64(90*time.Millisecond))
+	assert.GreaterOrEqual(t, int64(received[4].Sub(start)), int64(190*time.Millisecond))
+}
+
+func TestNotifier_Validate(t *testing.T) {
+	params := &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		MaxMessageSize:       5,
+	}
+
+	t.Run("Message size", func(t *testing.T) {
+		notifier := New("http://localhost", params)
+		errs := notifier.Validate([]byte("ok"), []byte("too large"), []byte("12345"))
+
+		require.Len(t, errs, 3)
+		assert.NoError(t, errs[0])
+		assert.True(t, errors.Is(errs[1], &NotifyErr{Type: TypeInvalidMessage}))
+		assert.NoError(t, errs[2])
+	})
+
+	t.Run("Invalid URL", func(t *testing.T) {
+		notifier := New("%", params) //nolint: staticcheck
+		errs := notifier.Validate([]byte("ok"))
+
+		assert.True(t, errors.Is(errs[0], &NotifyErr{Type: TypeSendError}))
+	})
+
+	t.Run("Notify rejects invalid messages", func(t *testing.T) {
+		var received int32
+		testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			atomic.AddInt32(&received, 1)
+			writer.WriteHeader(http.StatusOK)
+		}))
+		defer testSrv.Close()
+
+		notifier := New(testSrv.URL, params)
+		rejected := make(chan string, 1)
+		notifier.OnError(func(message []byte, err error) {
+			assert.True(t, errors.Is(err, &NotifyErr{Type: TypeInvalidMessage}))
+			rejected <- string(message)
+		})
+		n, err := notifier.Notify([]byte("too large"), []byte("ok"))
+		notifier.Wait()
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, n, "rejected message is not counted as scheduled")
+		assert.Equal(t, "too large", <-rejected)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&received))
+	})
+}
+
+func TestNotifier_Method(t *testing.T) {
+	methods := make(chan string, 1)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		assert.Equal(t, "message", string(body))
+		methods <- request.Method
+	}))
+	defer testSrv.Close()
+
+	tests := []struct {
+		method   string
+		expected string
+	}{
+		{"", http.MethodPost},
+		{http.MethodGet, http.MethodGet},
+		{http.MethodPut, http.MethodPut},
+		{http.MethodPatch, http.MethodPatch},
+		{"UNKNOWN", http.MethodPost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected+" for "+tt.method, func(t *testing.T) {
+			notifier := New(testSrv.URL, &ClientParams{
+				MaxConcurrentWorkers: 1,
+				MaxRequestRate:       time.Millisecond,
+				MaxRequestsPerRate:   1,
+				Method:               tt.method,
+			})
+			notifier.OnError(func(message []byte, err error) {
+				t.Errorf("unexpected error: %v", err)
+			})
+			_, err := notifier.Notify([]byte("message"))
+			require.NoError(t, err)
+			notifier.Wait()
+			assert.Equal(t, tt.expected, <-methods)
+		})
+	}
+
+	_, err := NewValidated(testSrv.URL, &ClientParams{Method: "UNKNOWN"})
+	assert.Error(t, err)
+}
+
+// countingTransport counts requests sent through the next transport.
+type countingTransport struct {
+	next     http.RoundTripper
+	requests int32
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.requests, 1)
+	return c.next.RoundTrip(req)
+}
+
+func TestNotifier_HTTPClient(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "wrapped", request.Header.Get("X-Wrapper"))
+	}))
+	defer testSrv.Close()
+
+	transport := &countingTransport{next: http.DefaultTransport}
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+		HTTPClient:           &http.Client{Transport: transport, Timeout: time.Minute},
+		TransportWrapper: func(next http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				req.Header.Set("X-Wrapper", "wrapped")
+				return next.RoundTrip(req)
+			})
+		},
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	_, err := notifier.Notify(generateTestMessages(2)...)
+	require.NoError(t, err)
+	notifier.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&transport.requests))
+	assert.Equal(t, time.Minute, notifier.client.Timeout)
+}
+
+// roundTripFunc implements http.RoundTripper interface using a function.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestNotifier_FailFastOnRequestError(t *testing.T) {
+	notifyInvalidURL := func(t *testing.T, failFast bool) (*Client, map[string]int) {
+		notifier := New("http://invalid host/", &ClientParams{
+			MaxConcurrentWorkers:   1,
+			MaxRequestRate:         time.Millisecond,
+			MaxRequestsPerRate:     1,
+			BlockWhenFull:          true,
+			FailFastOnRequestError: failFast,
+		})
+		var mu sync.Mutex
+		failures := make(map[string]int)
+		notifier.OnError(func(message []byte, err error) {
+			var nErr *NotifyErr
+			if !assert.True(t, errors.As(err, &nErr)) {
+				return
+			}
+			mu.Lock()
+			failures[nErr.Message]++
+			mu.Unlock()
+		})
+		_, _ = notifier.Notify(generateTestMessages(3)...)
+		notifier.Wait()
+		return notifier, failures
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		notifier, failures := notifyInvalidURL(t, false)
+		assert.Equal(t, map[string]int{msgSendErrorRequest: 3}, failures)
+		assert.NoError(t, notifier.ctx.Err())
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		notifier, failures := notifyInvalidURL(t, true)
+		assert.Equal(t, 1, failures[msgSendErrorRequest])
+		assert.Error(t, notifier.ctx.Err(), "client must be stopped")
+	})
+}
+
+func TestNotifier_Headers(t *testing.T) {
+	var requests int32
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+		assert.Equal(t, "Token secret", request.Header.Get("Authorization"))
+		assert.Equal(t, []string{"a", "b"}, request.Header.Values("X-Tenant-Id"))
+	}))
+	defer testSrv.Close()
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+	headers.Set("Authorization", "Token secret")
+	headers.Add("X-Tenant-ID", "a")
+	headers.Add("X-Tenant-ID", "b")
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   4,
+		Headers:              headers,
+	})
+	headers.Set("Authorization", "changed")
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+
+	_, err := notifier.Notify(generateTestMessages(4)...)
+	require.NoError(t, err)
+	notifier.Wait()
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+}
+
+func TestNotifier_AuthToken(t *testing.T) {
+	authorizations := make(chan string, 3)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		authorizations <- request.Header.Get("Authorization")
+	}))
+	defer testSrv.Close()
+
+	t.Run("Static", func(t *testing.T) {
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 1,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   1,
+			AuthToken:            "secret",
+		})
+		_, err := notifier.Notify([]byte("message"))
+		require.NoError(t, err)
+		notifier.Wait()
+		assert.Equal(t, "Bearer secret", <-authorizations)
+	})
+
+	t.Run("Func", func(t *testing.T) {
+		var calls int32
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 3,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   3,
+			AuthToken:            "ignored",
+			AuthTokenFunc: func() string {
+				return fmt.Sprintf("token-%d", atomic.AddInt32(&calls, 1))
+			},
+		})
+		_, err := notifier.Notify(generateTestMessages(3)...)
+		require.NoError(t, err)
+		notifier.Wait()
+		close(authorizations)
+
+		var received []string
+		for authorization := range authorizations {
+			received = append(received, authorization)
+		}
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+		assert.ElementsMatch(t, []string{"Bearer token-1", "Bearer token-2", "Bearer token-3"}, received)
+	})
+}
+
+func TestNotifier_BasicAuth(t *testing.T) {
+	type credentials struct {
+		user, password string
+		ok             bool
+	}
+	received := make(chan credentials, 1)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		user, password, ok := request.BasicAuth()
+		received <- credentials{user, password, ok}
+	}))
+	defer testSrv.Close()
+
+	notify := func(params *ClientParams) credentials {
+		params.MaxConcurrentWorkers = 1
+		params.MaxRequestRate = time.Millisecond
+		params.MaxRequestsPerRate = 1
+		notifier := New(testSrv.URL, params)
+		_, err := notifier.Notify([]byte("message"))
+		require.NoError(t, err)
+		notifier.Wait()
+		return <-received
+	}
+
+	assert.Equal(t, credentials{"user", "p@ss:word", true}, notify(&ClientParams{
+		BasicAuthUser:     "user",
+		BasicAuthPassword: "p@ss:word",
+	}))
+
+	headers := http.Header{}
+	headers.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("explicit:header")))
+	assert.Equal(t, credentials{"explicit", "header", true}, notify(&ClientParams{
+		Headers:           headers,
+		BasicAuthUser:     "user",
+		BasicAuthPassword: "password",
+	}))
+}
+
+func TestNotifier_RetryOnStatus(t *testing.T) {
+	var notFoundRequests, unavailableRequests int32
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		switch string(body) {
+		case "not found":
+			atomic.AddInt32(&notFoundRequests, 1)
+			writer.WriteHeader(http.StatusNotFound)
+		default:
+			atomic.AddInt32(&unavailableRequests, 1)
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 10,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   10,
+		MaxRetries:           2,
+		RetryOnStatus:        []int{http.StatusServiceUnavailable},
+	})
+	var mu sync.Mutex
+	failed := make(map[string]*NotifyErr)
+	notifier.OnError(func(message []byte, err error) {
+		var nErr *NotifyErr
+		if !assert.True(t, errors.As(err, &nErr)) {
+			return
+		}
+		assert.Equal(t, TypeSendError, nErr.Type)
+		assert.Equal(t, msgSendErrorStatus, nErr.Message)
+		mu.Lock()
+		failed[string(message)] = nErr
+		mu.Unlock()
+	})
+	n, err := notifier.Notify([]byte("not found"), []byte("unavailable"))
+	notifier.Wait()
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&notFoundRequests))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&unavailableRequests))
+	require.Len(t, failed, 2)
+	assert.Equal(t, http.StatusNotFound, failed["not found"].StatusCode)
+	assert.Equal(t, 1, failed["not found"].Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, failed["unavailable"].StatusCode)
+	assert.Equal(t, 3, failed["unavailable"].Attempts)
+}
+
+func TestNotifier_OnErrorDecide(t *testing.T) {
+	var conflictRequests, unavailableRequests int32
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		switch string(body) {
+		case "conflict":
+			if atomic.AddInt32(&conflictRequests, 1) == 1 {
+				writer.WriteHeader(http.StatusConflict)
+			}
+		default:
+			atomic.AddInt32(&unavailableRequests, 1)
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+		MaxRetries:           2,
+	})
+	notifier.OnErrorDecide(func(message []byte, err error) bool {
+		var nErr *NotifyErr
+		return errors.As(err, &nErr) && nErr.StatusCode == http.StatusConflict
+	})
+	failed := make(chan string, 2)
+	notifier.OnError(func(message []byte, err error) {
+		failed <- string(message)
+	})
+	_, err := notifier.Notify([]byte("conflict"), []byte("unavailable"))
+	require.NoError(t, err)
+	notifier.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&conflictRequests))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&unavailableRequests))
+	require.Len(t, failed, 1)
+	assert.Equal(t, "unavailable", <-failed)
+}
+
+func TestNotifier_RetryBackoff(t *testing.T) {
+	t.Run("Eventually delivered", func(t *testing.T) {
+		var requests int32
+		var mu sync.Mutex
+		var times []time.Time
+		testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			mu.Lock()
+			times = append(times, time.Now())
+			mu.Unlock()
+			switch atomic.AddInt32(&requests, 1) {
+			case 1:
+				// Connection error.
+				conn, _, _ := writer.(http.Hijacker).Hijack()
+				_ = conn.Close()
+			case 2:
+				writer.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer testSrv.Close()
+
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 1,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   1,
+			MaxRetries:           3,
+			RetryBackoff:         20 * time.Millisecond,
+		})
+		notifier.OnError(func(message []byte, err error) {
+			t.Errorf("unexpected error: %v", err)
+		})
+		_, err := notifier.Notify([]byte("message"))
+		require.NoError(t, err)
+		notifier.Wait()
+
+		assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, times, 3)
+		assert.GreaterOrEqual(t, int64(times[1].Sub(times[0])), int64(20*time.Millisecond))
+		assert.GreaterOrEqual(t, int64(times[2].Sub(times[1])), int64(40*time.Millisecond))
+	})
+
+	t.Run("Stop interrupts backoff", func(t *testing.T) {
+		testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer testSrv.Close()
+
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 1,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   1,
+			MaxRetries:           1,
+			RetryBackoff:         time.Hour,
+		})
+		failed := make(chan error, 1)
+		notifier.OnError(func(message []byte, err error) {
+			failed <- err
+		})
+		_, err := notifier.Notify([]byte("message"))
+		require.NoError(t, err)
+		time.Sleep(50 * time.Millisecond)
+
+		stopped := time.Now()
+		notifier.Stop()
+		notifier.Wait()
+		assert.Less(t, int64(time.Since(stopped)), int64(time.Second))
+
+		var nErr *NotifyErr
+		require.True(t, errors.As(<-failed, &nErr))
+		assert.Equal(t, TypeContextCanceled, nErr.Type)
+		assert.Equal(t, 1, nErr.Attempts)
+	})
+}
+
+func TestNotifier_RequestTimeout(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = ioutil.ReadAll(request.Body)
+		select {
+		case <-request.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		MaxRetries:           3,
+		RequestTimeout:       50 * time.Millisecond,
+	})
+	failed := make(chan error, 1)
+	notifier.OnError(func(message []byte, err error) {
+		failed <- err
+	})
+	start := time.Now()
+	_, err := notifier.Notify([]byte("slow"))
+	require.NoError(t, err)
+	notifier.Wait()
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+
+	var nErr *NotifyErr
+	require.True(t, errors.As(<-failed, &nErr))
+	assert.Equal(t, TypeTimeout, nErr.Type)
+	assert.Equal(t, msgSendErrorTimeout, nErr.Message)
+	assert.True(t, errors.Is(nErr, &NotifyErr{Type: TypeTimeout}))
+	assert.False(t, errors.Is(nErr, &NotifyErr{Type: TypeContextCanceled}))
+	assert.True(t, errors.Is(nErr, context.DeadlineExceeded))
+	assert.False(t, errors.Is(nErr, context.Canceled))
+}
+
+func TestNotifier_BlockWhenFull(t *testing.T) {
+	notifyBurst := func(t *testing.T, blockWhenFull bool) (int, int32, error) {
+		var delivered int32
+		testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			_, _ = ioutil.ReadAll(request.Body)
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&delivered, 1)
+		}))
+		defer testSrv.Close()
+
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 2,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   20,
+			BlockWhenFull:        blockWhenFull,
+		})
+		n, err := notifier.Notify(generateTestMessages(20)...)
+		notifier.Wait()
+		return n, atomic.LoadInt32(&delivered), err
+	}
+
+	t.Run("Lossy", func(t *testing.T) {
+		n, delivered, err := notifyBurst(t, false)
+		var nErr *NotifyErr
+		require.True(t, errors.As(err, &nErr))
+		assert.Equal(t, TypeWorkersLimitExceeded, nErr.Type)
+		assert.Less(t, n, 20)
+		assert.Equal(t, int32(n), delivered)
+	})
+
+	t.Run("Blocking", func(t *testing.T) {
+		n, delivered, err := notifyBurst(t, true)
+		require.NoError(t, err)
+		assert.Equal(t, 20, n)
+		assert.Equal(t, int32(20), delivered)
+	})
+
+	t.Run("Large batch", func(t *testing.T) {
+		const (
+			count   = 10000
+			workers = 4
+		)
+		var delivered, inFlight, maxInFlight int32
+		testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			_, _ = ioutil.ReadAll(request.Body)
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+					break
+				}
+			}
+			atomic.AddInt32(&delivered, 1)
+			atomic.AddInt32(&inFlight, -1)
+		}))
+		defer testSrv.Close()
+
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: workers,
+			MaxRequestRate:       time.Nanosecond,
+			MaxRequestsPerRate:   workers,
+			BlockWhenFull:        true,
+		})
+		notifier.OnError(func(message []byte, err error) {
+			t.Errorf("unexpected error: %v", err)
+		})
+		n, err := notifier.Notify(generateTestMessages(count)...)
+		require.NoError(t, err)
+		assert.Equal(t, count, n)
+		notifier.Wait()
+
+		assert.Equal(t, int32(count), atomic.LoadInt32(&delivered), "no message may be dropped")
+		assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(workers), "requests must be bounded by workers limit")
+	})
+
+	t.Run("Stop while blocked", func(t *testing.T) {
+		started := make(chan struct{}, 1)
+		testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			_, _ = ioutil.ReadAll(request.Body)
+			started <- struct{}{}
+			<-request.Context().Done()
+		}))
+		defer testSrv.Close()
+
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 1,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   1,
+			BlockWhenFull:        true,
+		})
+		_, err := notifier.Notify([]byte("busy"))
+		require.NoError(t, err)
+		<-started
+
+		type result struct {
+			n   int
+			err error
+		}
+		done := make(chan result)
+		messages := generateTestMessages(2)
+		go func() {
+			n, err := notifier.Notify(messages...)
+			done <- result{n: n, err: err}
+		}()
+
+		select {
+		case <-done:
+			t.Fatal("Notify must block while workers limit is exceeded")
+		case <-time.After(50 * time.Millisecond):
+		}
+		notifier.Stop()
+
+		select {
+		case res := <-done:
+			assert.Zero(t, res.n)
+			var nErr *NotifyErr
+			require.True(t, errors.As(res.err, &nErr))
+			assert.Equal(t, TypeContextCanceled, nErr.Type)
+			assert.Equal(t, messages, nErr.Remaining)
+		case <-time.After(time.Second):
+			t.Fatal("Notify must return after Stop")
+		}
+		notifier.Wait()
+	})
+}
+
+func TestNotifier_ZeroRequestRate(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 100,
+		MaxRequestRate:       0,
+		MaxRequestsPerRate:   0,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	n, err := notifier.Notify(generateTestMessages(100)...)
+	notifier.Wait()
+
+	require.NoError(t, err)
+	assert.Equal(t, 100, n)
+	assert.Equal(t, rate.Inf, notifier.requestsLimiter.Limit())
+}
+
+func TestNewValidated(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		notifier, err := NewValidated("", &ClientParams{MaxConcurrentWorkers: 1})
+		require.NoError(t, err)
+		assert.NotNil(t, notifier)
+
+		notifier, err = NewValidated("", nil)
+		require.NoError(t, err)
+		assert.NotNil(t, notifier)
+	})
+
+	t.Run("Negative request rate", func(t *testing.T) {
+		notifier, err := NewValidated("", &ClientParams{MaxRequestRate: -time.Second})
+		assert.Error(t, err)
+		assert.Nil(t, notifier)
+	})
+
+	t.Run("Negative retries", func(t *testing.T) {
+		_, err := NewValidated("", &ClientParams{MaxRetries: -1})
+		assert.Error(t, err)
+	})
+
+	t.Run("Required error handler", func(t *testing.T) {
+		_, err := NewValidated("", &ClientParams{MaxConcurrentWorkers: 1, RequireErrorHandler: true})
+		assert.Error(t, err)
+
+		failed := make(chan error, 1)
+		notifier, err := NewValidated("http://127.0.0.1:0", &ClientParams{
+			MaxConcurrentWorkers: 1,
+			RequireErrorHandler:  true,
+			ErrorHandler: func(message []byte, err error) {
+				failed <- err
+			},
+		})
+		require.NoError(t, err)
+		_, err = notifier.Notify([]byte("message"))
+		require.NoError(t, err)
+		notifier.Wait()
+		assert.Error(t, <-failed)
+	})
+}
+
+func TestNotifier_MaxRetriesFunc(t *testing.T) {
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		mu.Lock()
+		attempts[string(body)]++
+		attempt := attempts[string(body)]
+		mu.Unlock()
+		if attempt == 1 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+		MaxRetries:           5,
+		MaxRetriesFunc: func(message []byte) int {
+			if string(message) == "important" {
+				return 2
+			}
+			return 0
+		},
+	})
+	failed := make(chan string, 2)
+	notifier.OnError(func(message []byte, err error) {
+		failed <- string(message)
+	})
+	_, err := notifier.Notify([]byte("important"), []byte("regular"))
+	require.NoError(t, err)
+	notifier.Wait()
+	close(failed)
+
+	var failedMessages []string
+	for msg := range failed {
+		failedMessages = append(failedMessages, msg)
+	}
+	assert.Equal(t, []string{"regular"}, failedMessages)
+	assert.Equal(t, map[string]int{"important": 2, "regular": 1}, attempts)
+}
+
 func TestNotifier_Rlimit(t *testing.T) {
 	var rLimit syscall.Rlimit
 	err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rLimit)
 	require.NoError(t, err)
+	defer func(workers uint64) { DefaultParams.MaxConcurrentWorkers = workers }(DefaultParams.MaxConcurrentWorkers)
 	DefaultParams.MaxConcurrentWorkers = rLimit.Cur + 1
 
 	transport := getTestTransport()
@@ -204,6 +1434,23 @@ func TestNotifier_Rlimit(t *testing.T) {
 	assert.Equal(t, int(rLimit.Cur), cap(notifier.workersLimiter))
 }
 
+func TestNotifier_DefaultParamsUnchanged(t *testing.T) {
+	defaults := *DefaultParams
+
+	limited := getTestTransport()
+	limited.MaxConnsPerHost = 3
+	first := create("", nil, limited)
+	second := create("", nil, getTestTransport())
+
+	assert.Equal(t, 3, cap(first.workersLimiter))
+	assert.Equal(t, int(defaults.MaxConcurrentWorkers), cap(second.workersLimiter))
+	assert.Equal(t, defaults, *DefaultParams)
+
+	params := &ClientParams{MaxRequestsPerRate: 1}
+	create("", params, getTestTransport())
+	assert.Equal(t, uint64(0), params.MaxConcurrentWorkers)
+}
+
 // generateTestMessages generates messages array for testing purposes.
 func generateTestMessages(limit int) [][]byte {
 	messages := make([][]byte, limit)
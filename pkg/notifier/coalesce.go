@@ -0,0 +1,76 @@
+package notifier
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// repeatCountHeader is set to the number of coalesced messages.
+const repeatCountHeader = "X-Repeat-Count"
+
+// coalescer merges identical consecutive messages into single task with repeat count.
+// The last run of messages is held for the window after the last message,
+// so repeats from following calls can be merged too.
+type coalescer struct {
+	mu      sync.Mutex
+	window  time.Duration
+	pending *task
+	timer   *time.Timer
+	// gen is changed every time the timer is rearmed, so stale timer won't flush pending run.
+	gen int
+	// hold is called when a run is held and release when held run is not pending anymore.
+	hold, release func()
+	// flush schedules held run after the window.
+	flush func(t task)
+}
+
+// add merges messages with the pending run and returns tasks which are ready to be scheduled.
+func (c *coalescer) add(url string, messages [][]byte) []task {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var ready []task
+	for _, msg := range messages {
+		if c.pending != nil && c.pending.url == url && bytes.Equal(c.pending.message, msg) {
+			c.pending.repeatCount++
+			continue
+		}
+		if c.pending != nil {
+			ready = append(ready, *c.pending)
+			c.pending = nil
+		}
+		c.pending = &task{url: url, message: msg, dedupe: true, repeatCount: 1}
+	}
+
+	if c.timer != nil && c.timer.Stop() {
+		c.release()
+	}
+	c.timer = nil
+	c.gen++
+	if c.pending == nil {
+		return ready
+	}
+	if c.window <= 0 {
+		ready = append(ready, *c.pending)
+		c.pending = nil
+		return ready
+	}
+
+	gen := c.gen
+	c.hold()
+	c.timer = time.AfterFunc(c.window, func() {
+		c.mu.Lock()
+		if c.gen != gen {
+			c.mu.Unlock()
+			c.release()
+			return
+		}
+		held := c.pending
+		c.pending, c.timer = nil, nil
+		c.mu.Unlock()
+		c.flush(*held)
+		c.release()
+	})
+	return ready
+}
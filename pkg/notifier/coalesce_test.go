@@ -0,0 +1,120 @@
+package notifier
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_CoalesceConsecutive(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string]string)
+	var requests int
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		mu.Lock()
+		received[string(body)] = request.Header.Get(repeatCountHeader)
+		requests++
+		mu.Unlock()
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 5,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   5,
+		CoalesceConsecutive:  true,
+		CoalesceWindow:       50 * time.Millisecond,
+	})
+
+	t.Run("Single batch", func(t *testing.T) {
+		n, err := notifier.Notify([]byte("a"), []byte("a"), []byte("a"), []byte("b"))
+		notifier.Wait()
+
+		require.NoError(t, err)
+		assert.Equal(t, 4, n)
+		assert.Equal(t, map[string]string{"a": "3", "b": "1"}, received)
+		assert.Equal(t, 2, requests)
+	})
+
+	t.Run("Across calls within window", func(t *testing.T) {
+		received = make(map[string]string)
+		requests = 0
+
+		_, err := notifier.Notify([]byte("c"))
+		require.NoError(t, err)
+		_, err = notifier.Notify([]byte("c"), []byte("c"))
+		require.NoError(t, err)
+		notifier.Wait()
+
+		assert.Equal(t, map[string]string{"c": "3"}, received)
+		assert.Equal(t, 1, requests)
+	})
+}
+
+func TestNotifier_CoalesceWithoutWindow(t *testing.T) {
+	counts := make(chan string, 3)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		counts <- request.Header.Get(repeatCountHeader)
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 5,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   5,
+		CoalesceConsecutive:  true,
+	})
+	_, err := notifier.Notify([]byte("a"), []byte("a"))
+	require.NoError(t, err)
+	_, err = notifier.Notify([]byte("a"))
+	require.NoError(t, err)
+	notifier.Wait()
+	close(counts)
+
+	var got []string
+	for count := range counts {
+		got = append(got, count)
+	}
+	assert.ElementsMatch(t, []string{"2", "1"}, got)
+}
+
+func TestNotifier_CoalesceDraining(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		t.Error("held message must not be sent after Drain")
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 5,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   5,
+		CoalesceConsecutive:  true,
+		CoalesceWindow:       50 * time.Millisecond,
+	})
+	var mu sync.Mutex
+	var failed []error
+	notifier.OnError(func(message []byte, err error) {
+		mu.Lock()
+		failed = append(failed, err)
+		mu.Unlock()
+	})
+	_, err := notifier.Notify([]byte("a"), []byte("a"))
+	require.NoError(t, err)
+	notifier.Drain()
+	notifier.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, failed, 1, "held message must be reported once")
+	assert.True(t, errors.Is(failed[0], &NotifyErr{Type: TypeDraining}))
+}
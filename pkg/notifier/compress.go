@@ -0,0 +1,38 @@
+package notifier
+
+import (
+	"bytes"
+	"compress/gzip"
+	"sync"
+)
+
+// gzipEncoding is a value of Content-Encoding header of compressed requests.
+const gzipEncoding = "gzip"
+
+// gzipWriters keeps gzip writers for reuse, because every writer allocates large compression state.
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// shouldCompress reports if encoded body of the message should be compressed.
+func (c *Client) shouldCompress(message, body []byte) bool {
+	if c.compressFunc != nil {
+		return c.compressFunc(message)
+	}
+	return c.compress && len(body) >= c.compressMinSize
+}
+
+// gzipBody returns body compressed with gzip.
+func gzipBody(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(w)
+	w.Reset(&buf)
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
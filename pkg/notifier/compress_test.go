@@ -0,0 +1,135 @@
+package notifier
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_Compress(t *testing.T) {
+	small := "small"
+	large := strings.Repeat("large ", 100)
+
+	var mu sync.Mutex
+	received := make(map[string]string)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		encoding := request.Header.Get("Content-Encoding")
+		if encoding == gzipEncoding {
+			r, err := gzip.NewReader(bytes.NewReader(body))
+			if !assert.NoError(t, err) {
+				return
+			}
+			body, err = ioutil.ReadAll(r)
+			assert.NoError(t, err)
+		}
+		mu.Lock()
+		received[string(body)] = encoding
+		mu.Unlock()
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   4,
+		Compress:             true,
+		CompressMinSize:      100,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	_, err := notifier.Notify([]byte(small), []byte(large), []byte(large+"again"))
+	require.NoError(t, err)
+	notifier.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[string]string{small: "", large: gzipEncoding, large + "again": gzipEncoding}, received)
+
+	_, err = NewValidated(testSrv.URL, &ClientParams{CompressMinSize: -1})
+	assert.Error(t, err)
+}
+
+func TestNotifier_CompressFunc(t *testing.T) {
+	small := "small"
+	large := strings.Repeat("large ", 100)
+
+	var mu sync.Mutex
+	encodings := make(map[string]string)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		encoding := request.Header.Get("Content-Encoding")
+		if encoding == gzipEncoding {
+			r, err := gzip.NewReader(bytes.NewReader(body))
+			if !assert.NoError(t, err) {
+				return
+			}
+			body, err = ioutil.ReadAll(r)
+			assert.NoError(t, err)
+		}
+		mu.Lock()
+		encodings[string(body)] = encoding
+		mu.Unlock()
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+		CompressFunc: func(message []byte) bool {
+			return len(message) > 100
+		},
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	_, err := notifier.Notify([]byte(small), []byte(large))
+	require.NoError(t, err)
+	notifier.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[string]string{small: "", large: gzipEncoding}, encodings)
+}
+
+func BenchmarkGzipBody(b *testing.B) {
+	body := []byte(strings.Repeat("benchmark message ", 64))
+
+	b.Run("Pool", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := gzipBody(body); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+
+	b.Run("NoPool", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				var buf bytes.Buffer
+				w := gzip.NewWriter(&buf)
+				if _, err := w.Write(body); err != nil {
+					b.Fatal(err)
+				}
+				if err := w.Close(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,260 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// secretHeaderParts are parts of header names which are treated as secret and never exported by ExportConfig,
+// e.g. Authorization, X-Auth-Token, X-Api-Key, Cookie or X-Session-Id.
+var secretHeaderParts = []string{"auth", "token", "key", "secret", "password", "cookie", "session", "signature", "credential"}
+
+// isSecretHeader reports whether header key matches secretHeaderParts.
+func isSecretHeader(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range secretHeaderParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL replaces password of rawURL with "xxxxx". Invalid URLs are returned as is.
+func redactURL(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return u.Redacted()
+	}
+	return rawURL
+}
+
+// duration is time.Duration which is encoded to JSON as a string like "1m30s".
+type duration time.Duration
+
+// MarshalJSON implements json.Marshaler interface.
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+// clientConfig is a JSON representation of non-secret Client configuration.
+// Callbacks, signers, wrappers and credentials are not included. RequireErrorHandler is not included either,
+// because ErrorHandler can't be exported and the restored Client would be invalid.
+type clientConfig struct {
+	URL                    string         `json:"url"`
+	MaxConcurrentWorkers   uint64         `json:"max_concurrent_workers"`
+	MaxRequestRate         duration       `json:"max_request_rate"`
+	MaxRequestsPerRate     int            `json:"max_requests_per_rate"`
+	InitialBurst           int            `json:"initial_burst,omitempty"`
+	MinInterval            duration       `json:"min_interval,omitempty"`
+	MaxRetries             int            `json:"max_retries,omitempty"`
+	RequestTimeout         duration       `json:"request_timeout,omitempty"`
+	RetryBackoff           duration       `json:"retry_backoff,omitempty"`
+	RetryJitter            float64        `json:"retry_jitter,omitempty"`
+	MaxRetryAfter          duration       `json:"max_retry_after,omitempty"`
+	RetryOnStatus          []int          `json:"retry_on_status,omitempty"`
+	SuccessStatusCodes     []int          `json:"success_status_codes,omitempty"`
+	DedupeCount            int            `json:"dedupe_count,omitempty"`
+	SingleFlight           bool           `json:"single_flight,omitempty"`
+	BlockWhenFull          bool           `json:"block_when_full,omitempty"`
+	OverflowPolicy         OverflowPolicy `json:"overflow_policy,omitempty"`
+	MaxQueueDepth          int            `json:"max_queue_depth,omitempty"`
+	MaxInFlightBytes       int            `json:"max_in_flight_bytes,omitempty"`
+	Encoding               Encoding       `json:"encoding,omitempty"`
+	PayloadFormat          PayloadFormat  `json:"payload_format,omitempty"`
+	PayloadField           string         `json:"payload_field,omitempty"`
+	PauseErrorRate         float64        `json:"pause_error_rate,omitempty"`
+	PauseWindow            int            `json:"pause_window,omitempty"`
+	PauseCooldown          duration       `json:"pause_cooldown,omitempty"`
+	OfflineAfterFailures   int            `json:"offline_after_failures,omitempty"`
+	OfflineProbeInterval   duration       `json:"offline_probe_interval,omitempty"`
+	OfflineProbeURL        string         `json:"offline_probe_url,omitempty"`
+	AutoTuneErrorRate      float64        `json:"auto_tune_error_rate,omitempty"`
+	AutoTuneWindow         int            `json:"auto_tune_window,omitempty"`
+	HostHeader             string         `json:"host_header,omitempty"`
+	BatchCountHeader       string         `json:"batch_count_header,omitempty"`
+	DeadLetterCapacity     int            `json:"dead_letter_capacity,omitempty"`
+	CoalesceConsecutive    bool           `json:"coalesce_consecutive,omitempty"`
+	CoalesceWindow         duration       `json:"coalesce_window,omitempty"`
+	Partitions             int            `json:"partitions,omitempty"`
+	DialTimeout            duration       `json:"dial_timeout,omitempty"`
+	H2CUpgrade             bool           `json:"h2c_upgrade,omitempty"`
+	PinnedCertSHA256       [][]byte       `json:"pinned_cert_sha256,omitempty"`
+	MaxRequestsPerConn     int            `json:"max_requests_per_conn,omitempty"`
+	MaxTotalConns          int            `json:"max_total_conns,omitempty"`
+	CloseIdleOnStop        bool           `json:"close_idle_on_stop,omitempty"`
+	Accept                 string         `json:"accept,omitempty"`
+	CorrelationIDHeader    string         `json:"correlation_id_header,omitempty"`
+	SendStartHeader        bool           `json:"send_start_header,omitempty"`
+	MaxMessageSize         int            `json:"max_message_size,omitempty"`
+	ShadowURL              string         `json:"shadow_url,omitempty"`
+	IdempotencyKeys        bool           `json:"idempotency_keys,omitempty"`
+	StreamFrameSize        int            `json:"stream_frame_size,omitempty"`
+	SerializePerHost       bool           `json:"serialize_per_host,omitempty"`
+	BodyPrefix             []byte         `json:"body_prefix,omitempty"`
+	BodySuffix             []byte         `json:"body_suffix,omitempty"`
+	Compress               bool           `json:"compress,omitempty"`
+	CompressMinSize        int            `json:"compress_min_size,omitempty"`
+	Method                 string         `json:"method"`
+	FailFastOnRequestError bool           `json:"fail_fast_on_request_error,omitempty"`
+	Headers                http.Header    `json:"headers,omitempty"`
+}
+
+// ExportConfig returns non-secret configuration of the Client as JSON, which can be used by NewFromConfig
+// to create an equivalent Client. Credentials, secret headers (see isSecretHeader) and passwords of URLs
+// are not exported, as well as options which can't be represented in JSON, e.g. callbacks and signers.
+func (c *Client) ExportConfig() ([]byte, error) {
+	p := c.params
+	headers := c.headers.Clone()
+	for key := range headers {
+		if isSecretHeader(key) {
+			delete(headers, key)
+		}
+	}
+	if len(headers) == 0 {
+		headers = nil
+	}
+	return json.Marshal(clientConfig{
+		URL:                    redactURL(c.URL()),
+		MaxConcurrentWorkers:   p.MaxConcurrentWorkers,
+		MaxRequestRate:         duration(p.MaxRequestRate),
+		MaxRequestsPerRate:     p.MaxRequestsPerRate,
+		InitialBurst:           p.InitialBurst,
+		MinInterval:            duration(p.MinInterval),
+		MaxRetries:             p.MaxRetries,
+		RequestTimeout:         duration(p.RequestTimeout),
+		RetryBackoff:           duration(p.RetryBackoff),
+		RetryJitter:            p.RetryJitter,
+		MaxRetryAfter:          duration(p.MaxRetryAfter),
+		RetryOnStatus:          p.RetryOnStatus,
+		SuccessStatusCodes:     p.SuccessStatusCodes,
+		DedupeCount:            p.DedupeCount,
+		SingleFlight:           p.SingleFlight,
+		BlockWhenFull:          p.BlockWhenFull,
+		OverflowPolicy:         p.OverflowPolicy,
+		MaxQueueDepth:          p.MaxQueueDepth,
+		MaxInFlightBytes:       p.MaxInFlightBytes,
+		Encoding:               p.Encoding,
+		PayloadFormat:          p.PayloadFormat,
+		PayloadField:           p.PayloadField,
+		PauseErrorRate:         p.PauseErrorRate,
+		PauseWindow:            p.PauseWindow,
+		PauseCooldown:          duration(p.PauseCooldown),
+		OfflineAfterFailures:   p.OfflineAfterFailures,
+		OfflineProbeInterval:   duration(p.OfflineProbeInterval),
+		OfflineProbeURL:        redactURL(p.OfflineProbeURL),
+		AutoTuneErrorRate:      p.AutoTuneErrorRate,
+		AutoTuneWindow:         p.AutoTuneWindow,
+		HostHeader:             p.HostHeader,
+		BatchCountHeader:       p.BatchCountHeader,
+		DeadLetterCapacity:     p.DeadLetterCapacity,
+		CoalesceConsecutive:    p.CoalesceConsecutive,
+		CoalesceWindow:         duration(p.CoalesceWindow),
+		Partitions:             p.Partitions,
+		DialTimeout:            duration(p.DialTimeout),
+		H2CUpgrade:             p.H2CUpgrade,
+		PinnedCertSHA256:       p.PinnedCertSHA256,
+		MaxRequestsPerConn:     p.MaxRequestsPerConn,
+		MaxTotalConns:          p.MaxTotalConns,
+		CloseIdleOnStop:        p.CloseIdleOnStop,
+		Accept:                 p.Accept,
+		CorrelationIDHeader:    p.CorrelationIDHeader,
+		SendStartHeader:        p.SendStartHeader,
+		MaxMessageSize:         p.MaxMessageSize,
+		ShadowURL:              redactURL(p.ShadowURL),
+		IdempotencyKeys:        p.IdempotencyKeys,
+		StreamFrameSize:        p.StreamFrameSize,
+		SerializePerHost:       p.SerializePerHost,
+		BodyPrefix:             p.BodyPrefix,
+		BodySuffix:             p.BodySuffix,
+		Compress:               p.Compress,
+		CompressMinSize:        p.CompressMinSize,
+		Method:                 c.method,
+		FailFastOnRequestError: p.FailFastOnRequestError,
+		Headers:                headers,
+	})
+}
+
+// NewFromConfig creates new Client using configuration exported by ExportConfig.
+// Params are validated the same way as NewValidated does.
+func NewFromConfig(data []byte) (*Client, error) {
+	var cfg clientConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return NewValidated(cfg.URL, &ClientParams{
+		MaxConcurrentWorkers:   cfg.MaxConcurrentWorkers,
+		MaxRequestRate:         time.Duration(cfg.MaxRequestRate),
+		MaxRequestsPerRate:     cfg.MaxRequestsPerRate,
+		InitialBurst:           cfg.InitialBurst,
+		MinInterval:            time.Duration(cfg.MinInterval),
+		MaxRetries:             cfg.MaxRetries,
+		RequestTimeout:         time.Duration(cfg.RequestTimeout),
+		RetryBackoff:           time.Duration(cfg.RetryBackoff),
+		RetryJitter:            cfg.RetryJitter,
+		MaxRetryAfter:          time.Duration(cfg.MaxRetryAfter),
+		RetryOnStatus:          cfg.RetryOnStatus,
+		SuccessStatusCodes:     cfg.SuccessStatusCodes,
+		DedupeCount:            cfg.DedupeCount,
+		SingleFlight:           cfg.SingleFlight,
+		BlockWhenFull:          cfg.BlockWhenFull,
+		OverflowPolicy:         cfg.OverflowPolicy,
+		MaxQueueDepth:          cfg.MaxQueueDepth,
+		MaxInFlightBytes:       cfg.MaxInFlightBytes,
+		Encoding:               cfg.Encoding,
+		PayloadFormat:          cfg.PayloadFormat,
+		PayloadField:           cfg.PayloadField,
+		PauseErrorRate:         cfg.PauseErrorRate,
+		PauseWindow:            cfg.PauseWindow,
+		PauseCooldown:          time.Duration(cfg.PauseCooldown),
+		OfflineAfterFailures:   cfg.OfflineAfterFailures,
+		OfflineProbeInterval:   time.Duration(cfg.OfflineProbeInterval),
+		OfflineProbeURL:        cfg.OfflineProbeURL,
+		AutoTuneErrorRate:      cfg.AutoTuneErrorRate,
+		AutoTuneWindow:         cfg.AutoTuneWindow,
+		HostHeader:             cfg.HostHeader,
+		BatchCountHeader:       cfg.BatchCountHeader,
+		DeadLetterCapacity:     cfg.DeadLetterCapacity,
+		CoalesceConsecutive:    cfg.CoalesceConsecutive,
+		CoalesceWindow:         time.Duration(cfg.CoalesceWindow),
+		Partitions:             cfg.Partitions,
+		DialTimeout:            time.Duration(cfg.DialTimeout),
+		H2CUpgrade:             cfg.H2CUpgrade,
+		PinnedCertSHA256:       cfg.PinnedCertSHA256,
+		MaxRequestsPerConn:     cfg.MaxRequestsPerConn,
+		MaxTotalConns:          cfg.MaxTotalConns,
+		CloseIdleOnStop:        cfg.CloseIdleOnStop,
+		Accept:                 cfg.Accept,
+		CorrelationIDHeader:    cfg.CorrelationIDHeader,
+		SendStartHeader:        cfg.SendStartHeader,
+		MaxMessageSize:         cfg.MaxMessageSize,
+		ShadowURL:              cfg.ShadowURL,
+		IdempotencyKeys:        cfg.IdempotencyKeys,
+		StreamFrameSize:        cfg.StreamFrameSize,
+		SerializePerHost:       cfg.SerializePerHost,
+		BodyPrefix:             cfg.BodyPrefix,
+		BodySuffix:             cfg.BodySuffix,
+		Compress:               cfg.Compress,
+		CompressMinSize:        cfg.CompressMinSize,
+		Method:                 cfg.Method,
+		FailFastOnRequestError: cfg.FailFastOnRequestError,
+		Headers:                cfg.Headers,
+	})
+}
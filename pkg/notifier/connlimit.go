@@ -0,0 +1,147 @@
+package notifier
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// countingConn is a connection which counts requests sent through it.
+type countingConn struct {
+	net.Conn
+	requests int32
+}
+
+// countingDialer wraps connections created by dial with countingConn.
+func countingDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &countingConn{Conn: conn}, nil
+	}
+}
+
+// connLimiter closes connections which have been used for maxRequests requests,
+// so transport has to establish new connection for the next request.
+type connLimiter struct {
+	next        http.RoundTripper
+	maxRequests int32
+}
+
+// CloseIdleConnections closes idle connections of the next transport if it supports that.
+func (l *connLimiter) CloseIdleConnections() {
+	if t, ok := l.next.(interface{ CloseIdleConnections() }); ok {
+		t.CloseIdleConnections()
+	}
+}
+
+// RoundTrip implements http.RoundTripper interface.
+func (l *connLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	var conn net.Conn
+	var exhausted bool
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			counting := unwrapCountingConn(info.Conn)
+			if counting == nil {
+				return
+			}
+			conn = info.Conn
+			exhausted = atomic.AddInt32(&counting.requests, 1) >= l.maxRequests
+		},
+	}
+	resp, err := l.next.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err != nil || !exhausted {
+		return resp, err
+	}
+	if resp.Body == nil || resp.Body == http.NoBody {
+		// Connection has been already returned to the pool.
+		conn.Close() //nolint: errcheck, gosec
+		return resp, nil
+	}
+	resp.Body = &closeConnBody{ReadCloser: resp.Body, conn: conn}
+	return resp, nil
+}
+
+// unwrapCountingConn returns countingConn used by conn or nil.
+func unwrapCountingConn(conn net.Conn) *countingConn {
+	if tlsConn, ok := conn.(interface{ NetConn() net.Conn }); ok {
+		conn = tlsConn.NetConn()
+	}
+	counting, _ := conn.(*countingConn)
+	return counting
+}
+
+// closeConnBody closes connection after response body is closed.
+type closeConnBody struct {
+	io.ReadCloser
+	conn net.Conn
+}
+
+// Close implements io.Closer interface.
+func (b *closeConnBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.conn.Close() //nolint: errcheck, gosec
+	return err
+}
+
+// limitedConn releases its slot of total connections limit when it's closed.
+type limitedConn struct {
+	net.Conn
+	release func()
+	once    sync.Once
+}
+
+// Close implements net.Conn interface.
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+// closeIdleInterval is an interval of closing idle connections while dial waits for a free slot.
+const closeIdleInterval = 50 * time.Millisecond
+
+// limitingDialer allows at most cap(slots) open connections created by dial across all hosts.
+// While there are no free slots it periodically closes idle connections using closeIdle,
+// because idle connections to other hosts may hold all slots.
+func limitingDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error), slots chan struct{}, closeIdle func()) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if err := acquireSlot(ctx, slots, closeIdle); err != nil {
+			return nil, err
+		}
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			<-slots
+			return nil, err
+		}
+		return &limitedConn{Conn: conn, release: func() { <-slots }}, nil
+	}
+}
+
+// acquireSlot takes a slot calling closeIdle every closeIdleInterval until it's free or ctx is done.
+func acquireSlot(ctx context.Context, slots chan struct{}, closeIdle func()) error {
+	select {
+	case slots <- struct{}{}:
+		return nil
+	default:
+	}
+	ticker := time.NewTicker(closeIdleInterval)
+	defer ticker.Stop()
+	for {
+		closeIdle()
+		select {
+		case slots <- struct{}{}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
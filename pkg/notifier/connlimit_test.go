@@ -0,0 +1,102 @@
+package notifier
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_MaxRequestsPerConn(t *testing.T) {
+	var mu sync.Mutex
+	var remoteAddrs []string
+	testSrv := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mu.Lock()
+		remoteAddrs = append(remoteAddrs, request.RemoteAddr)
+		mu.Unlock()
+		writer.WriteHeader(http.StatusOK)
+	}))
+	var newConns int
+	testSrv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			newConns++
+			mu.Unlock()
+		}
+	}
+	testSrv.Start()
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		MaxRequestsPerConn:   2,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	for i := 0; i < 6; i++ {
+		_, err := notifier.Notify([]byte("message"))
+		require.NoError(t, err)
+		notifier.Wait()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, remoteAddrs, 6)
+	for i := 0; i < len(remoteAddrs); i += 2 {
+		assert.Equal(t, remoteAddrs[i], remoteAddrs[i+1], "requests %d and %d should share connection", i, i+1)
+		if i > 0 {
+			assert.NotEqual(t, remoteAddrs[i-1], remoteAddrs[i], "request %d should use new connection", i)
+		}
+	}
+	assert.Equal(t, 3, newConns)
+}
+
+func TestNotifier_MaxTotalConns(t *testing.T) {
+	var active, maxActive, delivered int32
+	handler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			max := atomic.LoadInt32(&maxActive)
+			if n <= max || atomic.CompareAndSwapInt32(&maxActive, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+		atomic.AddInt32(&delivered, 1)
+	})
+	first := httptest.NewServer(handler)
+	defer first.Close()
+	second := httptest.NewServer(handler)
+	defer second.Close()
+
+	notifier := New(first.URL, &ClientParams{
+		MaxConcurrentWorkers: 10,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   10,
+		BlockWhenFull:        true,
+		MaxTotalConns:        2,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	for i := 0; i < 10; i++ {
+		_, err := notifier.NotifyTo(first.URL, generateTestMessages(2)...)
+		require.NoError(t, err)
+		_, err = notifier.NotifyTo(second.URL, generateTestMessages(2)...)
+		require.NoError(t, err)
+	}
+	notifier.Wait()
+
+	assert.Equal(t, int32(40), atomic.LoadInt32(&delivered))
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxActive), int32(2))
+}
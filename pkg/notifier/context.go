@@ -0,0 +1,69 @@
+package notifier
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// valueContext is a context which takes values from values context first.
+// It's used to pass values of a caller context to the context derived from Client context.
+type valueContext struct {
+	context.Context
+	values context.Context
+}
+
+// Value implements context.Context interface.
+func (v *valueContext) Value(key interface{}) interface{} {
+	if value := v.values.Value(key); value != nil {
+		return value
+	}
+	return v.Context.Value(key)
+}
+
+// batchContext returns context derived from Client context which is also canceled when ctx is done
+// and has values of ctx. Returned finish func must be called for each of tasks,
+// resources are released after the last call.
+func (c *Client) batchContext(ctx context.Context, tasks int) (context.Context, func()) {
+	batchCtx, cancel := context.WithCancel(c.context())
+	if tasks == 0 {
+		cancel()
+		return batchCtx, func() {}
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-batchCtx.Done():
+		}
+	}()
+
+	left := int64(tasks)
+	finish := func() {
+		if atomic.AddInt64(&left, -1) == 0 {
+			cancel()
+		}
+	}
+	return &valueContext{Context: batchCtx, values: ctx}, finish
+}
+
+// requestContext returns context of requests sending the message derived by contextFunc if it is set.
+// Returned context is canceled when parent is done even if contextFunc returns context which isn't its child.
+func (c *Client) requestContext(parent context.Context, message []byte) (context.Context, context.CancelFunc) {
+	if c.contextFunc == nil {
+		return parent, func() {}
+	}
+	derived := c.contextFunc(parent, message)
+	if derived == nil {
+		return parent, func() {}
+	}
+	ctx, cancel := context.WithCancel(derived)
+	go func() {
+		select {
+		case <-parent.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
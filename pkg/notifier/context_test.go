@@ -0,0 +1,155 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type correlationIDKey struct{}
+
+func TestNotifier_CorrelationID(t *testing.T) {
+	ids := make(chan string, 2)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ids <- request.Header.Get("X-Correlation-ID")
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		CorrelationIDKey:     correlationIDKey{},
+		CorrelationIDHeader:  "X-Correlation-ID",
+	})
+
+	ctx := context.WithValue(context.Background(), correlationIDKey{}, "request-42")
+	_, err := notifier.NotifyContext(ctx, []byte("with id"))
+	require.NoError(t, err)
+	notifier.Wait()
+	assert.Equal(t, "request-42", <-ids)
+
+	_, err = notifier.NotifyContext(context.Background(), []byte("without id"))
+	require.NoError(t, err)
+	notifier.Wait()
+	assert.Empty(t, <-ids)
+}
+
+func TestNotifier_ContextFunc(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-request.Context().Done():
+		}
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+		ContextFunc: func(ctx context.Context, message []byte) context.Context {
+			if string(message) != "urgent" {
+				return ctx
+			}
+			ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+			time.AfterFunc(time.Second, cancel)
+			return ctx
+		},
+	})
+	failed := make(chan error, 2)
+	notifier.OnError(func(message []byte, err error) {
+		assert.Equal(t, "urgent", string(message))
+		failed <- err
+	})
+	_, err := notifier.Notify([]byte("urgent"), []byte("patient"))
+	require.NoError(t, err)
+	notifier.Wait()
+
+	require.Len(t, failed, 1)
+	assert.True(t, errors.Is(<-failed, context.DeadlineExceeded))
+}
+
+func TestNotifier_NotifyContextCancel(t *testing.T) {
+	started := make(chan struct{}, 2)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		if strings.HasPrefix(string(body), "canceled") {
+			started <- struct{}{}
+			<-request.Context().Done()
+		}
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   4,
+	})
+	var mu sync.Mutex
+	failed := make(map[string]error)
+	notifier.OnError(func(message []byte, err error) {
+		mu.Lock()
+		failed[string(message)] = err
+		mu.Unlock()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := notifier.NotifyContext(ctx, []byte("canceled 1"), []byte("canceled 2"))
+	require.NoError(t, err)
+	<-started
+	<-started
+	cancel()
+
+	_, err = notifier.NotifyContext(context.Background(), []byte("other 1"), []byte("other 2"))
+	require.NoError(t, err)
+	notifier.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, failed, 2)
+	for _, message := range []string{"canceled 1", "canceled 2"} {
+		var nErr *NotifyErr
+		require.True(t, errors.As(failed[message], &nErr), message)
+		assert.Equal(t, TypeContextCanceled, nErr.Type)
+		assert.True(t, errors.Is(nErr, context.Canceled))
+	}
+}
+
+func TestNotifier_NotifyContextCancelRateWait(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Hour,
+		MaxRequestsPerRate:   1,
+	})
+	failed := make(chan error, 1)
+	notifier.OnError(func(message []byte, err error) {
+		failed <- err
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	// The second message waits for the rate limiter until ctx is canceled.
+	_, err := notifier.NotifyContext(ctx, []byte("sent"), []byte("waiting"))
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	notifier.Wait()
+
+	var nErr *NotifyErr
+	require.True(t, errors.As(<-failed, &nErr))
+	assert.Equal(t, TypeContextCanceled, nErr.Type)
+	assert.True(t, errors.Is(nErr, context.Canceled))
+}
@@ -0,0 +1,109 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// DeadLetter is a message which has not been delivered together with the reason.
+type DeadLetter struct {
+	Message []byte
+	Err     error
+}
+
+// deadLetterStore keeps last undelivered messages. When it's full the oldest message is dropped.
+type deadLetterStore struct {
+	mu       sync.Mutex
+	items    []DeadLetter
+	capacity int
+}
+
+func newDeadLetterStore(capacity int) *deadLetterStore {
+	return &deadLetterStore{capacity: capacity}
+}
+
+// add stores undelivered message.
+func (s *deadLetterStore) add(letter DeadLetter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.items) == s.capacity {
+		s.items = s.items[1:]
+	}
+	s.items = append(s.items, letter)
+}
+
+// list returns copy of stored messages.
+func (s *deadLetterStore) list() []DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]DeadLetter(nil), s.items...)
+}
+
+// take removes all stored messages and returns them.
+func (s *deadLetterStore) take() []DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.items
+	s.items = nil
+	return items
+}
+
+// DeadLetters returns messages which have not been delivered.
+// Messages are collected only if ClientParams.DeadLetterCapacity is greater than zero.
+func (c *Client) DeadLetters() []DeadLetter {
+	if c.deadLetters == nil {
+		return nil
+	}
+	return c.deadLetters.list()
+}
+
+// ReplayDeadLetters removes all currently dead-lettered messages and schedules them again through the normal send path.
+// It blocks until all of them are handled or ctx is done and returns the number of delivered messages.
+//
+// Messages which fail again or could not be scheduled are dead-lettered again,
+// so it is safe to call it while other messages keep failing.
+func (c *Client) ReplayDeadLetters(ctx context.Context) (int, error) {
+	if c.deadLetters == nil {
+		return 0, nil
+	}
+
+	letters := c.deadLetters.take()
+	var delivered int64
+	var wg sync.WaitGroup
+	tasks := make([]task, len(letters))
+	for i, letter := range letters {
+		letter := letter
+		tasks[i] = task{
+			url:            c.URL(),
+			message:        letter.Message,
+			skipDeadLetter: true,
+			done: func(err error) {
+				if err == nil {
+					atomic.AddInt64(&delivered, 1)
+				} else {
+					c.deadLetters.add(DeadLetter{Message: letter.Message, Err: err})
+				}
+				wg.Done()
+			},
+		}
+	}
+
+	wg.Add(len(tasks))
+	n, err := c.schedule(tasks)
+	if err != nil && n == 0 {
+		return 0, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return int(atomic.LoadInt64(&delivered)), err
+	case <-ctx.Done():
+		return int(atomic.LoadInt64(&delivered)), ctx.Err()
+	}
+}
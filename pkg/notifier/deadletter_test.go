@@ -0,0 +1,77 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_ReplayDeadLetters(t *testing.T) {
+	var failing int32 = 1
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 5,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   5,
+		DeadLetterCapacity:   10,
+	})
+	messages := generateTestMessages(3)
+	_, err := notifier.Notify(messages...)
+	require.NoError(t, err)
+	notifier.Wait()
+
+	letters := notifier.DeadLetters()
+	require.Len(t, letters, 3)
+	for _, letter := range letters {
+		assert.Contains(t, messages, letter.Message)
+		assert.True(t, errors.Is(letter.Err, &NotifyErr{Type: TypeSendError}))
+	}
+
+	atomic.StoreInt32(&failing, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	n, err := notifier.ReplayDeadLetters(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Empty(t, notifier.DeadLetters())
+}
+
+func TestNotifier_ReplayDeadLettersFailAgain(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 5,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   5,
+		DeadLetterCapacity:   2,
+	})
+	_, err := notifier.Notify(generateTestMessages(3)...)
+	require.NoError(t, err)
+	notifier.Wait()
+	require.Len(t, notifier.DeadLetters(), 2)
+
+	n, err := notifier.ReplayDeadLetters(context.Background())
+
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Len(t, notifier.DeadLetters(), 2)
+}
@@ -0,0 +1,91 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// deadLetterFile appends dead letters to a file as JSON lines.
+type deadLetterFile struct {
+	mu  sync.Mutex
+	f   *os.File
+	err error
+}
+
+// deadLetterEntry is a JSON line written to dead letters file.
+type deadLetterEntry struct {
+	Time    time.Time       `json:"time"`
+	Message []byte          `json:"message"`
+	Error   json.RawMessage `json:"error"`
+}
+
+// PersistDeadLetters appends every dead-lettered message to the file at path as a JSON line, so it survives restarts.
+// Every line contains time, base64-encoded message and error in the format of NotifyErr.MarshalJSON,
+// e.g. {"time":"2021-01-01T00:00:00Z","message":"aGVsbG8=","error":{"type":"SendError",...}}.
+// Messages are written independently of ClientParams.DeadLetterCapacity. Use Close to flush the file on shutdown.
+// It's safe to call it while messages are being sent, the file of the previous call is closed.
+func (c *Client) PersistDeadLetters(path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("unable to open dead letters file: %w", err)
+	}
+	c.deadLetterFileMu.Lock()
+	old := c.deadLetterFile
+	c.deadLetterFile = &deadLetterFile{f: f}
+	c.deadLetterFileMu.Unlock()
+	if old != nil {
+		_ = old.close()
+	}
+	return nil
+}
+
+// deadLettersFile returns file set by PersistDeadLetters or nil.
+func (c *Client) deadLettersFile() *deadLetterFile {
+	c.deadLetterFileMu.RLock()
+	defer c.deadLetterFileMu.RUnlock()
+	return c.deadLetterFile
+}
+
+// write appends letter to the file. The first write error is kept and returned by close.
+func (d *deadLetterFile) write(letter DeadLetter) {
+	entry := deadLetterEntry{Time: time.Now().UTC(), Message: letter.Message}
+	if m, ok := letter.Err.(json.Marshaler); ok {
+		entry.Error, _ = m.MarshalJSON()
+	}
+	if entry.Error == nil {
+		entry.Error, _ = json.Marshal(map[string]string{"message": fmt.Sprint(letter.Err)})
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.f == nil {
+		return
+	}
+	if _, err := d.f.Write(append(line, '\n')); err != nil && d.err == nil {
+		d.err = err
+	}
+}
+
+// close syncs file to the disk and closes it.
+func (d *deadLetterFile) close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.f == nil {
+		return d.err
+	}
+	if err := d.f.Sync(); err != nil && d.err == nil {
+		d.err = err
+	}
+	if err := d.f.Close(); err != nil && d.err == nil {
+		d.err = err
+	}
+	d.f = nil
+	return d.err
+}
@@ -0,0 +1,96 @@
+package notifier
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_PersistDeadLetters(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer testSrv.Close()
+
+	path := filepath.Join(t.TempDir(), "deadletters.jsonl")
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 3,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   3,
+	})
+	require.NoError(t, notifier.PersistDeadLetters(path))
+	_, err := notifier.Notify([]byte("1"), []byte("2"), []byte("3"))
+	require.NoError(t, err)
+	notifier.Wait()
+	require.NoError(t, notifier.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var messages []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry struct {
+			Time    time.Time `json:"time"`
+			Message []byte    `json:"message"`
+			Error   struct {
+				Type   string `json:"type"`
+				Status int    `json:"status"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		assert.False(t, entry.Time.IsZero())
+		assert.Equal(t, "SendError", entry.Error.Type)
+		assert.Equal(t, http.StatusServiceUnavailable, entry.Error.Status)
+		messages = append(messages, string(entry.Message))
+	}
+	require.NoError(t, scanner.Err())
+	sort.Strings(messages)
+	assert.Equal(t, []string{"1", "2", "3"}, messages)
+}
+
+func TestNotifier_PersistDeadLettersReplaced(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer testSrv.Close()
+
+	dir := t.TempDir()
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+	})
+	require.NoError(t, notifier.PersistDeadLetters(filepath.Join(dir, "first.jsonl")))
+	old := notifier.deadLettersFile()
+	_, err := notifier.Notify([]byte("1"), []byte("2"))
+	require.NoError(t, err)
+	// File is replaced while workers write dead letters, which is caught by the race detector if unguarded.
+	require.NoError(t, notifier.PersistDeadLetters(filepath.Join(dir, "second.jsonl")))
+	notifier.Wait()
+
+	old.mu.Lock()
+	assert.Nil(t, old.f, "file of the previous call must be closed")
+	old.mu.Unlock()
+
+	_, err = notifier.Notify([]byte("3"))
+	require.NoError(t, err)
+	notifier.Wait()
+	require.NoError(t, notifier.Close())
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "second.jsonl"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"message":"Mw=="`)
+}
@@ -0,0 +1,46 @@
+package notifier
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+// dedupeWindow remembers hashes of last N messages and reports repeats within that window.
+// It uses ring buffer to know which hash should be forgotten and set for fast lookups.
+type dedupeWindow struct {
+	mu   sync.Mutex
+	ring [][sha256.Size]byte
+	next int
+	size int
+	set  map[[sha256.Size]byte]struct{}
+}
+
+// newDedupeWindow creates dedupeWindow which remembers last count messages.
+func newDedupeWindow(count int) *dedupeWindow {
+	return &dedupeWindow{
+		ring: make([][sha256.Size]byte, count),
+		set:  make(map[[sha256.Size]byte]struct{}, count),
+	}
+}
+
+// add remembers message and returns true.
+// If message is already in the window it returns false and window stays unchanged.
+func (d *dedupeWindow) add(message []byte) bool {
+	hash := sha256.Sum256(message)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.set[hash]; ok {
+		return false
+	}
+	if d.size == len(d.ring) {
+		delete(d.set, d.ring[d.next])
+	} else {
+		d.size++
+	}
+	d.ring[d.next] = hash
+	d.set[hash] = struct{}{}
+	d.next = (d.next + 1) % len(d.ring)
+	return true
+}
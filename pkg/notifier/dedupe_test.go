@@ -0,0 +1,51 @@
+package notifier
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupeWindow_Add(t *testing.T) {
+	window := newDedupeWindow(2)
+
+	assert.True(t, window.add([]byte("a")))
+	assert.False(t, window.add([]byte("a")))
+	assert.True(t, window.add([]byte("b")))
+	assert.True(t, window.add([]byte("c")))
+	assert.True(t, window.add([]byte("a")))
+	assert.False(t, window.add([]byte("c")))
+}
+
+func TestNotifier_DedupeCount(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		mu.Lock()
+		received = append(received, string(body))
+		mu.Unlock()
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 10,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   10,
+		DedupeCount:          2,
+	})
+	messages := [][]byte{[]byte("a"), []byte("b"), []byte("a"), []byte("c"), []byte("d"), []byte("a")}
+	n, err := notifier.Notify(messages...)
+	notifier.Wait()
+
+	require.NoError(t, err)
+	assert.Equal(t, len(messages), n)
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d", "a"}, received)
+}
@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"errors"
+	"time"
+)
+
+// NotifyAt works the same way as Notify, but messages are held until at and scheduled then.
+// Messages are scheduled immediately if at has already passed, and the result of Notify is returned.
+// Otherwise it returns number of held messages. Messages which can't be scheduled at that time
+// are reported to OnError handler, e.g. if workers limit is exceeded or Client has been stopped in the meantime.
+// Wait waits for held messages too. Messages are never coalesced.
+func (c *Client) NotifyAt(at time.Time, messages ...[]byte) (int, error) {
+	tasks := messageTasks(c.URL(), messages)
+	delay := time.Until(at)
+	if delay <= 0 || c.acceptErr() != nil {
+		return c.schedule(tasks)
+	}
+
+	ctx := c.context()
+	c.workers.Add(1)
+	go func() {
+		defer c.workers.Done()
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		_, err := c.schedule(tasks)
+		var notifyErr *NotifyErr
+		// Messages rejected because of draining have already been reported by schedule.
+		if errors.As(err, &notifyErr) && notifyErr.Type != TypeDraining {
+			for _, msg := range notifyErr.Remaining[notifyErr.Queued:] {
+				c.notifyError(msg, notifyErr)
+			}
+		}
+	}()
+	return len(tasks), nil
+}
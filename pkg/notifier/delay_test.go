@@ -0,0 +1,108 @@
+package notifier
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_NotifyAt(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	received := make(chan string, 2)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		received <- string(body)
+	}))
+	defer testSrv.Close()
+
+	newNotifier := func(t *testing.T) *Client {
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 2,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   2,
+		})
+		notifier.OnError(func(message []byte, err error) {
+			t.Errorf("unexpected error: %v", err)
+		})
+		return notifier
+	}
+
+	t.Run("Future", func(t *testing.T) {
+		notifier := newNotifier(t)
+		start := time.Now()
+		n, err := notifier.NotifyAt(start.Add(delay), []byte("delayed"))
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		select {
+		case <-received:
+			t.Fatal("message must not be sent before scheduled time")
+		case <-time.After(delay / 2):
+		}
+		assert.Equal(t, "delayed", <-received)
+		elapsed := time.Since(start)
+		assert.GreaterOrEqual(t, int64(elapsed), int64(delay))
+		assert.Less(t, int64(elapsed), int64(2*delay))
+		notifier.Wait()
+	})
+
+	t.Run("Overdue", func(t *testing.T) {
+		notifier := newNotifier(t)
+		n, err := notifier.NotifyAt(time.Now().Add(-time.Minute), []byte("overdue"))
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		notifier.Wait()
+		assert.Equal(t, "overdue", <-received)
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 1,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   1,
+		})
+		failed := make(chan error, 1)
+		notifier.OnError(func(message []byte, err error) {
+			failed <- err
+		})
+		_, err := notifier.NotifyAt(time.Now().Add(time.Hour), []byte("never"))
+		require.NoError(t, err)
+		notifier.Stop()
+		notifier.Wait()
+
+		var nErr *NotifyErr
+		require.True(t, errors.As(<-failed, &nErr))
+		assert.Equal(t, TypeContextCanceled, nErr.Type)
+		assert.Empty(t, received)
+	})
+	t.Run("Drain", func(t *testing.T) {
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 2,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   2,
+		})
+		failed := make(chan error, 4)
+		notifier.OnError(func(message []byte, err error) {
+			failed <- err
+		})
+		_, err := notifier.NotifyAt(time.Now().Add(delay), []byte("a"), []byte("b"))
+		require.NoError(t, err)
+		notifier.Drain()
+		notifier.Wait()
+		close(failed)
+
+		var errs []error
+		for err := range failed {
+			assert.True(t, errors.Is(err, &NotifyErr{Type: TypeDraining}))
+			errs = append(errs, err)
+		}
+		assert.Len(t, errs, 2, "every held message must be reported once")
+		assert.Empty(t, received)
+	})
+}
@@ -0,0 +1,16 @@
+package notifier
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// errDraining is returned by acceptErr after Drain has been called.
+var errDraining = errors.New("client is draining")
+
+// Drain stops accepting new messages, so following calls fail with TypeDraining error.
+// Unlike Stop it doesn't cancel anything: already scheduled and queued messages are sent and retried as usual.
+// Use Wait to wait for them, and Stop or Close to release resources after that.
+func (c *Client) Drain() {
+	atomic.StoreInt32(&c.draining, 1)
+}
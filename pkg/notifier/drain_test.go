@@ -0,0 +1,64 @@
+package notifier
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_Drain(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	var received int32
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = ioutil.ReadAll(request.Body)
+		started <- struct{}{}
+		select {
+		case <-release:
+		case <-request.Context().Done():
+			return
+		}
+		atomic.AddInt32(&received, 1)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+	})
+	var rejected int32
+	notifier.OnError(func(message []byte, err error) {
+		var nErr *NotifyErr
+		if assert.True(t, errors.As(err, &nErr)) {
+			assert.Equal(t, TypeDraining, nErr.Type, "only new messages can fail")
+		}
+		atomic.AddInt32(&rejected, 1)
+	})
+	_, err := notifier.Notify(generateTestMessages(2)...)
+	require.NoError(t, err)
+	<-started
+	<-started
+
+	notifier.Drain()
+	n, err := notifier.Notify([]byte("late"))
+	assert.Zero(t, n)
+	var nErr *NotifyErr
+	require.True(t, errors.As(err, &nErr))
+	assert.Equal(t, TypeDraining, nErr.Type)
+	assert.True(t, errors.Is(err, errDraining))
+	assert.Equal(t, [][]byte{[]byte("late")}, nErr.Remaining)
+
+	close(release)
+	notifier.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&received), "scheduled messages must complete")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&rejected))
+	assert.Equal(t, uint64(1), notifier.Stats().Rejected)
+}
@@ -0,0 +1,59 @@
+package notifier
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// Encoding defines how message bytes are encoded before sending.
+// It is useful for text-only transports which can't handle raw binary data.
+type Encoding int
+
+const (
+	// EncodingNone sends message bytes as is.
+	EncodingNone Encoding = iota
+	// EncodingBase64 sends message encoded using standard base64 encoding.
+	EncodingBase64
+	// EncodingHex sends message encoded as hexadecimal string.
+	EncodingHex
+)
+
+// contentTypeText used as Content-Type of encoded messages.
+const contentTypeText = "text/plain; charset=us-ascii"
+
+// encode returns encoded message.
+func (e Encoding) encode(message []byte) []byte {
+	switch e {
+	case EncodingBase64:
+		body := make([]byte, base64.StdEncoding.EncodedLen(len(message)))
+		base64.StdEncoding.Encode(body, message)
+		return body
+	case EncodingHex:
+		body := make([]byte, hex.EncodedLen(len(message)))
+		hex.Encode(body, message)
+		return body
+	default:
+		return message
+	}
+}
+
+// contentType returns Content-Type of encoded message. It returns empty string if content type is unknown.
+func (e Encoding) contentType() string {
+	switch e {
+	case EncodingBase64, EncodingHex:
+		return contentTypeText
+	default:
+		return ""
+	}
+}
+
+// frameBody adds BodyPrefix and BodySuffix to the encoded message.
+func (c *Client) frameBody(body []byte) []byte {
+	if len(c.bodyPrefix) == 0 && len(c.bodySuffix) == 0 {
+		return body
+	}
+	framed := make([]byte, 0, len(c.bodyPrefix)+len(body)+len(c.bodySuffix))
+	framed = append(framed, c.bodyPrefix...)
+	framed = append(framed, body...)
+	return append(framed, c.bodySuffix...)
+}
@@ -0,0 +1,93 @@
+package notifier
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_Encoding(t *testing.T) {
+	message := []byte{0x00, 0xff, 0x10, '\n', 'm', 's', 'g'}
+
+	tests := []struct {
+		name     string
+		encoding Encoding
+		decode   func(string) ([]byte, error)
+	}{
+		{name: "Base64", encoding: EncodingBase64, decode: base64.StdEncoding.DecodeString},
+		{name: "Hex", encoding: EncodingHex, decode: hex.DecodeString},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			received := make(chan []byte, 1)
+			testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				assert.Equal(t, contentTypeText, request.Header.Get("Content-Type"))
+				body, _ := ioutil.ReadAll(request.Body)
+				decoded, err := tt.decode(string(body))
+				assert.NoError(t, err)
+				received <- decoded
+				writer.WriteHeader(http.StatusOK)
+			}))
+			defer testSrv.Close()
+
+			notifier := New(testSrv.URL, &ClientParams{
+				MaxConcurrentWorkers: 1,
+				MaxRequestRate:       time.Millisecond,
+				MaxRequestsPerRate:   1,
+				Encoding:             tt.encoding,
+			})
+			_, err := notifier.Notify(message)
+			notifier.Wait()
+
+			require.NoError(t, err)
+			assert.Equal(t, message, <-received)
+		})
+	}
+}
+
+// digestSigner sets SHA-256 digest of the body to the Digest header.
+type digestSigner struct{}
+
+func (digestSigner) Sign(req *http.Request, body []byte) error {
+	sum := sha256.Sum256(body)
+	req.Header.Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(sum[:]))
+	return nil
+}
+
+func TestNotifier_BodyPrefixSuffix(t *testing.T) {
+	received := make(chan string, 1)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		sum := sha256.Sum256(body)
+		assert.Equal(t, "SHA-256="+base64.StdEncoding.EncodeToString(sum[:]), request.Header.Get("Digest"))
+		received <- string(body)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		Encoding:             EncodingHex,
+		BodyPrefix:           []byte("<start>"),
+		BodySuffix:           []byte("<end>\n"),
+		Signer:               digestSigner{},
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	_, err := notifier.Notify([]byte("message"))
+	require.NoError(t, err)
+	notifier.Wait()
+
+	assert.Equal(t, "<start>"+hex.EncodeToString([]byte("message"))+"<end>\n", <-received)
+}
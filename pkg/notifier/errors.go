@@ -1,29 +1,74 @@
 package notifier
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 )
 
+// ErrorType is a type of NotifyErr.
+type ErrorType int
+
 const (
 	// TypeContextCanceled used by NotifyErr when context has been canceled.
-	TypeContextCanceled = iota
+	TypeContextCanceled ErrorType = iota
 	// TypeWorkersLimitExceeded used by NotifyErr when workers limit exceeded.
 	TypeWorkersLimitExceeded
 	// TypeSendError used by NotifyErr when unable to send a message.
 	TypeSendError
+	// TypeInvalidMessage used by NotifyErr when message is rejected before sending.
+	TypeInvalidMessage
+	// TypeTimeout used by NotifyErr when message has not been sent within ClientParams.RequestTimeout.
+	TypeTimeout
+	// TypeDraining used by NotifyErr when message is rejected because Client is draining, see Client.Drain.
+	TypeDraining
 )
 
+// String returns human-readable name of the type.
+func (t ErrorType) String() string {
+	switch t {
+	case TypeContextCanceled:
+		return "ContextCanceled"
+	case TypeWorkersLimitExceeded:
+		return "WorkersLimitExceeded"
+	case TypeSendError:
+		return "SendError"
+	case TypeInvalidMessage:
+		return "InvalidMessage"
+	case TypeTimeout:
+		return "Timeout"
+	case TypeDraining:
+		return "Draining"
+	default:
+		return fmt.Sprintf("ErrorType(%d)", int(t))
+	}
+}
+
 const (
 	msgSendErrorRequest     = "Fail send message, unable to create request"
 	msgSendErrorRateLimiter = "Fail send message, rate limiter error"
 	msgSendErrorClient      = "Fail send message, unable to do request"
+	msgSendErrorDNS         = "Fail send message, unable to resolve host"
+	msgSendErrorStatus      = "Fail send message, unexpected response status"
+	msgSendErrorSign        = "Fail send message, unable to sign request"
+	msgSendErrorResponse    = "Fail send message, invalid response"
+	msgSendErrorTimeout     = "Fail send message, request timed out"
 )
 
 // NotifyErr custom error used by the Client.
 type NotifyErr struct {
-	Type    int
+	Type    ErrorType
 	Message string
 	Err     error
+	// StatusCode is a response status code if server responded, otherwise it is 0.
+	StatusCode int
+	// Remaining contains messages which have not been scheduled because of the error.
+	Remaining [][]byte
+	// Queued is a number of first Remaining messages which have been put to the overflow queue
+	// and will be sent later, see ClientParams.MaxQueueDepth.
+	Queued int
+	// Attempts is a number of requests made to deliver the message.
+	Attempts int
 }
 
 // Error implements error interface.
@@ -42,3 +87,50 @@ func (e *NotifyErr) Is(target error) bool {
 	}
 	return e.Type == t.Type
 }
+
+// MarshalJSON implements json.Marshaler interface.
+// Type is marshaled as its name and Err as its message.
+func (e *NotifyErr) MarshalJSON() ([]byte, error) {
+	var errMsg string
+	if e.Err != nil {
+		errMsg = e.Err.Error()
+	}
+	return json.Marshal(struct {
+		Type     string `json:"type"`
+		Message  string `json:"message"`
+		Error    string `json:"error,omitempty"`
+		Status   int    `json:"status,omitempty"`
+		Attempts int    `json:"attempts,omitempty"`
+	}{
+		Type:     e.Type.String(),
+		Message:  e.Message,
+		Error:    errMsg,
+		Status:   e.StatusCode,
+		Attempts: e.Attempts,
+	})
+}
+
+// ScheduleErr is returned by NotifyAll if some messages have not been scheduled.
+type ScheduleErr struct {
+	// Errors contains error of every message which has not been scheduled in order of messages.
+	// Remaining of every error contains just that message.
+	Errors []*NotifyErr
+}
+
+// Error implements error interface.
+func (e *ScheduleErr) Error() string {
+	if len(e.Errors) == 0 {
+		return "all messages have been scheduled"
+	}
+	return fmt.Sprintf("%d messages have not been scheduled, first error: %v", len(e.Errors), e.Errors[0])
+}
+
+// Is reports whether any of the errors matches target.
+func (e *ScheduleErr) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,10 +1,13 @@
 package notifier
 
 import (
+	"encoding/json"
 	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNotifyErr_Error(t *testing.T) {
@@ -36,3 +39,32 @@ func TestNotifyErr_Is(t *testing.T) {
 	}
 	assert.True(t, errors.Is(err, &NotifyErr{Type: TypeContextCanceled}))
 }
+
+func TestNotifyErr_MarshalJSON(t *testing.T) {
+	err := &NotifyErr{
+		Type:       TypeSendError,
+		Message:    msgSendErrorStatus,
+		Err:        errors.New("503 Service Unavailable"),
+		StatusCode: http.StatusServiceUnavailable,
+		Attempts:   3,
+	}
+
+	data, marshalErr := json.Marshal(err)
+	require.NoError(t, marshalErr)
+	assert.JSONEq(t, `{
+		"type": "SendError",
+		"message": "Fail send message, unexpected response status",
+		"error": "503 Service Unavailable",
+		"status": 503,
+		"attempts": 3
+	}`, string(data))
+}
+
+func TestErrorType_String(t *testing.T) {
+	assert.Equal(t, "ContextCanceled", TypeContextCanceled.String())
+	assert.Equal(t, "WorkersLimitExceeded", TypeWorkersLimitExceeded.String())
+	assert.Equal(t, "InvalidMessage", TypeInvalidMessage.String())
+	assert.Equal(t, "Timeout", TypeTimeout.String())
+	assert.Equal(t, "Draining", TypeDraining.String())
+	assert.Equal(t, "ErrorType(42)", ErrorType(42).String())
+}
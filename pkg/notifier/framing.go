@@ -0,0 +1,139 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Requests with framed bodies have "X-Body-Framing: length-prefixed" header.
+const (
+	framingHeader      = "X-Body-Framing"
+	framingLengthValue = "length-prefixed"
+	frameHeaderSize    = 4
+)
+
+// FrameWriter writes content to the underlying writer as length-prefixed frames.
+// Close must be called to write terminating frame.
+//
+// Wire format: body is a sequence of frames. Every frame is a 4 bytes big-endian unsigned length
+// followed by that many bytes of content. Frame with zero length terminates the body,
+// so receiver can detect truncated bodies.
+type FrameWriter struct {
+	w      io.Writer
+	size   int
+	header [frameHeaderSize]byte
+}
+
+// NewFrameWriter creates FrameWriter which writes frames of up to size bytes of content.
+func NewFrameWriter(w io.Writer, size int) *FrameWriter {
+	return &FrameWriter{w: w, size: size}
+}
+
+// Write implements io.Writer interface. Content is split into frames of up to size bytes.
+func (f *FrameWriter) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		n := len(p)
+		if n > f.size {
+			n = f.size
+		}
+		if err := f.writeFrame(p[:n]); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Close writes terminating frame. It doesn't close the underlying writer.
+func (f *FrameWriter) Close() error {
+	return f.writeFrame(nil)
+}
+
+func (f *FrameWriter) writeFrame(content []byte) error {
+	binary.BigEndian.PutUint32(f.header[:], uint32(len(content)))
+	if _, err := f.w.Write(f.header[:]); err != nil {
+		return err
+	}
+	_, err := f.w.Write(content)
+	return err
+}
+
+// FrameReader reads content of length-prefixed frames written by FrameWriter.
+type FrameReader struct {
+	r    io.Reader
+	left uint32
+	done bool
+}
+
+// NewFrameReader creates FrameReader which reads frames from r.
+func NewFrameReader(r io.Reader) *FrameReader {
+	return &FrameReader{r: r}
+}
+
+// Read implements io.Reader interface. It returns io.ErrUnexpectedEOF if stream ends before terminating frame.
+func (f *FrameReader) Read(p []byte) (int, error) {
+	for f.left == 0 {
+		if f.done {
+			return 0, io.EOF
+		}
+		var header [frameHeaderSize]byte
+		if _, err := io.ReadFull(f.r, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, fmt.Errorf("unable to read frame header: %w", err)
+		}
+		f.left = binary.BigEndian.Uint32(header[:])
+		f.done = f.left == 0
+	}
+	if uint32(len(p)) > f.left {
+		p = p[:f.left]
+	}
+	n, err := f.r.Read(p)
+	f.left -= uint32(n)
+	if errors.Is(err, io.EOF) {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// frameEncoder reads content of src as length-prefixed frames.
+type frameEncoder struct {
+	src    io.Reader
+	chunk  []byte
+	buf    bytes.Buffer
+	writer *FrameWriter
+	done   bool
+}
+
+// newFrameEncoder creates reader which frames content of src into frames of up to size bytes.
+func newFrameEncoder(src io.Reader, size int) *frameEncoder {
+	e := &frameEncoder{src: src, chunk: make([]byte, size)}
+	e.writer = NewFrameWriter(&e.buf, size)
+	return e
+}
+
+// Read implements io.Reader interface.
+func (e *frameEncoder) Read(p []byte) (int, error) {
+	for e.buf.Len() == 0 {
+		if e.done {
+			return 0, io.EOF
+		}
+		n, err := e.src.Read(e.chunk)
+		if n > 0 {
+			_, _ = e.writer.Write(e.chunk[:n])
+		}
+		if errors.Is(err, io.EOF) {
+			_ = e.writer.Close()
+			e.done = true
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	return e.buf.Read(p)
+}
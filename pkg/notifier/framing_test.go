@@ -0,0 +1,81 @@
+package notifier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewFrameWriter(&buf, 4)
+	n, err := writer.Write([]byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	require.NoError(t, writer.Close())
+
+	assert.Equal(t, []byte("\x00\x00\x00\x04hell\x00\x00\x00\x01o\x00\x00\x00\x00"), buf.Bytes())
+
+	content, err := ioutil.ReadAll(NewFrameReader(&buf))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+}
+
+func TestFrameReader_Truncated(t *testing.T) {
+	_, err := ioutil.ReadAll(NewFrameReader(bytes.NewReader([]byte("\x00\x00\x00\x04hell"))))
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+}
+
+func TestNotifier_StreamFrameSize(t *testing.T) {
+	const frameSize = 64 << 10
+	payload := make([]byte, 1<<20+123)
+	_, err := rand.Read(payload)
+	require.NoError(t, err)
+
+	received := make(chan []byte, 1)
+	frames := make(chan int, 1)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, framingLengthValue, request.Header.Get(framingHeader))
+		body, err := ioutil.ReadAll(request.Body)
+		assert.NoError(t, err)
+
+		var count int
+		for rest := body; len(rest) >= frameHeaderSize; count++ {
+			length := binary.BigEndian.Uint32(rest)
+			assert.LessOrEqual(t, length, uint32(frameSize))
+			rest = rest[frameHeaderSize+int(length):]
+		}
+		frames <- count
+
+		content, err := ioutil.ReadAll(NewFrameReader(bytes.NewReader(body)))
+		assert.NoError(t, err)
+		received <- content
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		StreamFrameSize:      frameSize,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	_, err = notifier.NotifyReaders(bytes.NewReader(payload))
+	require.NoError(t, err)
+	notifier.Wait()
+
+	assert.Equal(t, payload, <-received)
+	assert.GreaterOrEqual(t, <-frames, len(payload)/frameSize+2)
+}
@@ -0,0 +1,57 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+)
+
+// gate blocks senders while it is closed. Zero value is an open gate.
+type gate struct {
+	mu     sync.Mutex
+	closed chan struct{}
+}
+
+// close makes following wait calls block until the gate is opened. It returns false if gate was already closed.
+func (g *gate) close() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed != nil {
+		return false
+	}
+	g.closed = make(chan struct{})
+	return true
+}
+
+// open releases all waiters.
+func (g *gate) open() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed != nil {
+		close(g.closed)
+		g.closed = nil
+	}
+}
+
+// isClosed reports whether the gate is closed.
+func (g *gate) isClosed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.closed != nil
+}
+
+// wait blocks while the gate is closed. It returns context error if ctx is done before the gate is opened.
+func (g *gate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	closed := g.closed
+	g.mu.Unlock()
+	if closed == nil {
+		return nil
+	}
+
+	select {
+	case <-closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
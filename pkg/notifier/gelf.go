@@ -0,0 +1,76 @@
+package notifier
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// GELF chunking parameters, see https://docs.graylog.org/docs/gelf#gelf-via-udp.
+const (
+	gelfChunkSize       = 1420
+	gelfChunkHeaderSize = 12
+	gelfMaxChunks       = 128
+)
+
+// gelfChunkMagic starts every chunk of chunked GELF message.
+var gelfChunkMagic = [2]byte{0x1e, 0x0f}
+
+// gelfSender sends messages to Graylog GELF UDP input selected by "gelf://host:port" URL.
+// Message is sent as is, so it should be a GELF JSON document, optionally compressed.
+// Messages larger than a datagram are split into GELF chunks.
+type gelfSender struct {
+	conn      net.Conn
+	chunkSize int
+}
+
+// newGELFSender creates gelfSender for the URL.
+func newGELFSender(u *url.URL) (Sender, error) {
+	conn, err := net.Dial("udp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+	return &gelfSender{conn: conn, chunkSize: gelfChunkSize}, nil
+}
+
+// Send implements Sender interface.
+func (g *gelfSender) Send(ctx context.Context, message []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(message) <= g.chunkSize {
+		_, err := g.conn.Write(message)
+		return err
+	}
+
+	dataSize := g.chunkSize - gelfChunkHeaderSize
+	count := (len(message) + dataSize - 1) / dataSize
+	if count > gelfMaxChunks {
+		return fmt.Errorf("message of %d bytes exceeds %d GELF chunks", len(message), gelfMaxChunks)
+	}
+	chunk := make([]byte, 0, g.chunkSize)
+	chunk = append(chunk, gelfChunkMagic[:]...)
+	chunk = append(chunk, make([]byte, 8)...)
+	if _, err := rand.Read(chunk[2:10]); err != nil {
+		return err
+	}
+	chunk = append(chunk, 0, byte(count))
+	for seq := 0; seq < count; seq++ {
+		end := (seq + 1) * dataSize
+		if end > len(message) {
+			end = len(message)
+		}
+		chunk[10] = byte(seq)
+		if _, err := g.conn.Write(append(chunk[:gelfChunkHeaderSize], message[seq*dataSize:end]...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements Sender interface.
+func (g *gelfSender) Close() error {
+	return g.conn.Close()
+}
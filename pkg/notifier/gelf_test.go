@@ -0,0 +1,64 @@
+package notifier
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_GELF(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close() //nolint: errcheck
+
+	small := `{"version":"1.1","host":"test","short_message":"small"}`
+	large := `{"version":"1.1","host":"test","short_message":"` + strings.Repeat("large ", 1000) + `"}`
+
+	notifier := New("gelf://"+conn.LocalAddr().String(), &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		BlockWhenFull:        true,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	_, err = notifier.Notify([]byte(small), []byte(large))
+	require.NoError(t, err)
+	notifier.Wait()
+	require.NoError(t, notifier.Close())
+
+	var received []string
+	chunks := make(map[string][][]byte)
+	buf := make([]byte, 65536)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	for len(received) < 2 {
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		datagram := append([]byte(nil), buf[:n]...)
+		assert.LessOrEqual(t, len(datagram), gelfChunkSize)
+		if !bytes.HasPrefix(datagram, gelfChunkMagic[:]) {
+			received = append(received, string(datagram))
+			continue
+		}
+
+		id, seq, count := string(datagram[2:10]), int(datagram[10]), int(datagram[11])
+		if chunks[id] == nil {
+			chunks[id] = make([][]byte, count)
+		}
+		chunks[id][seq] = datagram[gelfChunkHeaderSize:]
+		complete := true
+		for _, chunk := range chunks[id] {
+			complete = complete && chunk != nil
+		}
+		if complete {
+			received = append(received, string(bytes.Join(chunks[id], nil)))
+		}
+	}
+	assert.Equal(t, []string{small, large}, received)
+}
@@ -0,0 +1,332 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+const (
+	// frameHeaderLen is a length of HTTP/2 frame header.
+	frameHeaderLen = 9
+	// headersFrameType is a type of HEADERS frame, which may have padding length and priority before header block.
+	headersFrameType = 0x1
+	// goAwayFrameType is a type of GOAWAY frame, which carries last stream ID in its payload.
+	goAwayFrameType = 0x7
+	// flagPadded and flagPriority are flags of HEADERS frame.
+	flagPadded   = 0x8
+	flagPriority = 0x20
+	// upgradeStreamID is a stream used by the server for the response to the upgrade request.
+	upgradeStreamID = 1
+	// upgradeStreamShift is added to stream IDs of the client, so they don't collide with upgradeStreamID.
+	upgradeStreamShift = 2
+)
+
+// h2cUpgradeSettings is a value of HTTP2-Settings header of the upgrade request.
+// It sets SETTINGS_HEADER_TABLE_SIZE to zero, so server never adds headers to HPACK dynamic table
+// and the response to the upgrade request can be skipped without breaking decoding of following responses.
+var h2cUpgradeSettings = base64.RawURLEncoding.EncodeToString([]byte{0, byte(http2.SettingHeaderTableSize), 0, 0, 0, 0})
+
+// restoreHeaderTableSize is HPACK Dynamic Table Size Update to the default 4096 bytes (RFC 7541 Section 6.3).
+// Server created by golang.org/x/net/http2/h2c applies table size of HTTP2-Settings to decoding of requests too,
+// so it's prepended to the first header block of the client to keep server decoder in sync with the client encoder.
+var restoreHeaderTableSize = []byte{0x3f, 0xe1, 0x1f}
+
+// h2cUpgradeTransport sends requests over cleartext HTTP/2 connections established
+// using HTTP/1.1 "Upgrade: h2c" handshake. A single connection is kept for every host.
+type h2cUpgradeTransport struct {
+	transport *http2.Transport
+	dialer    *net.Dialer
+
+	mu    sync.Mutex
+	conns map[string]*http2.ClientConn
+}
+
+// newH2CUpgradeTransport creates transport configured by params.
+func newH2CUpgradeTransport(params *ClientParams) *h2cUpgradeTransport {
+	return &h2cUpgradeTransport{
+		transport: &http2.Transport{AllowHTTP: true},
+		dialer:    &net.Dialer{Timeout: params.DialTimeout, KeepAlive: 30 * time.Second},
+		conns:     make(map[string]*http2.ClientConn),
+	}
+}
+
+// RoundTrip implements http.RoundTripper interface.
+func (t *h2cUpgradeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "http" {
+		return nil, fmt.Errorf("h2c upgrade: unsupported scheme %q", req.URL.Scheme)
+	}
+	cc, err := t.conn(req)
+	if err != nil {
+		return nil, err
+	}
+	return cc.RoundTrip(req)
+}
+
+// CloseIdleConnections shuts down all connections gracefully, so in-flight requests are not interrupted.
+func (t *h2cUpgradeTransport) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for addr, cc := range t.conns {
+		go cc.Shutdown(context.Background()) //nolint: errcheck
+		delete(t.conns, addr)
+	}
+}
+
+// conn returns connection to the host of the request, upgrading a new one if there is no usable connection.
+func (t *h2cUpgradeTransport) conn(req *http.Request) (*http2.ClientConn, error) {
+	addr := req.URL.Host
+	if req.URL.Port() == "" {
+		addr = net.JoinHostPort(req.URL.Hostname(), "80")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if cc, ok := t.conns[addr]; ok && cc.CanTakeNewRequest() {
+		return cc, nil
+	}
+	conn, err := t.upgrade(req, addr)
+	if err != nil {
+		return nil, err
+	}
+	cc, err := t.transport.NewClientConn(conn)
+	if err != nil {
+		conn.Close() //nolint: errcheck, gosec
+		return nil, err
+	}
+	t.conns[addr] = cc
+	return cc, nil
+}
+
+// upgrade dials addr and upgrades the connection to HTTP/2 using bodiless OPTIONS request to the URL of req.
+// Server handles the upgrade request as usual, and the response to it is discarded.
+func (t *h2cUpgradeTransport) upgrade(req *http.Request, addr string) (net.Conn, error) {
+	ctx := req.Context()
+	conn, err := t.dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	upgradeReq := &http.Request{
+		Method: http.MethodOptions,
+		URL:    &url.URL{Scheme: req.URL.Scheme, Host: req.URL.Host, Path: req.URL.Path, RawPath: req.URL.RawPath},
+		Host:   req.Host,
+		Header: http.Header{
+			"Connection":     {"Upgrade, HTTP2-Settings"},
+			"Upgrade":        {"h2c"},
+			"Http2-Settings": {h2cUpgradeSettings},
+		},
+	}
+	if err = upgradeReq.Write(conn); err != nil {
+		conn.Close() //nolint: errcheck, gosec
+		return nil, fmt.Errorf("h2c upgrade: %w", err)
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, upgradeReq)
+	if err != nil {
+		conn.Close() //nolint: errcheck, gosec
+		return nil, fmt.Errorf("h2c upgrade: %w", err)
+	}
+	resp.Body.Close() //nolint: errcheck, gosec
+	if resp.StatusCode != http.StatusSwitchingProtocols || !strings.EqualFold(resp.Header.Get("Upgrade"), "h2c") {
+		conn.Close() //nolint: errcheck, gosec
+		return nil, fmt.Errorf("h2c upgrade: server responded with status %s", resp.Status)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	return &upgradedConn{
+		Conn: conn,
+		r:    r,
+		in:   frameRewriter{mapID: serverStreamID},
+		out:  frameRewriter{skip: len(http2.ClientPreface), mapID: clientStreamID, inject: restoreHeaderTableSize},
+	}, nil
+}
+
+// serverStreamID maps stream ID of frames received from server to stream ID known to http2.ClientConn.
+// Frames of the upgrade stream are dropped.
+func serverStreamID(id uint32) (uint32, bool) {
+	switch {
+	case id == 0:
+		return 0, true
+	case id == upgradeStreamID:
+		return 0, false
+	default:
+		return id - upgradeStreamShift, true
+	}
+}
+
+// clientStreamID maps stream ID of frames sent by http2.ClientConn to stream ID sent to server.
+func clientStreamID(id uint32) (uint32, bool) {
+	if id == 0 {
+		return 0, true
+	}
+	return id + upgradeStreamShift, true
+}
+
+// upgradedConn is a connection upgraded to HTTP/2 by the upgrade request. It rewrites stream IDs,
+// so http2.ClientConn, which always starts from stream 1, doesn't collide with the upgrade stream.
+type upgradedConn struct {
+	net.Conn
+	r *bufio.Reader
+	// in and out are used only by reading and writing goroutine of http2.ClientConn respectively.
+	in, out frameRewriter
+	buf     []byte
+	pending []byte
+	written []byte
+}
+
+// Read implements net.Conn interface.
+func (c *upgradedConn) Read(p []byte) (int, error) {
+	for len(c.pending) == 0 {
+		if c.buf == nil {
+			c.buf = make([]byte, 32<<10)
+		}
+		n, err := c.r.Read(c.buf)
+		c.pending = c.in.rewrite(c.pending[:0], c.buf[:n])
+		if err != nil && len(c.pending) == 0 {
+			return 0, err
+		}
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// Write implements net.Conn interface.
+func (c *upgradedConn) Write(p []byte) (int, error) {
+	c.written = c.out.rewrite(c.written[:0], p)
+	if _, err := c.Conn.Write(c.written); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// frameRewriter rewrites stream IDs of HTTP/2 frames passing through it in chunks of any size.
+type frameRewriter struct {
+	// skip is a number of bytes passed as is before the first frame, e.g. client preface.
+	skip int
+	// mapID returns new stream ID or false if the frame must be dropped.
+	mapID func(id uint32) (uint32, bool)
+	// inject is prepended to the header block of the first HEADERS frame.
+	inject []byte
+
+	head    [frameHeaderLen + 5 + 1]byte
+	headLen int
+	// payload is a number of remaining payload bytes of the current frame.
+	payload uint32
+	drop    bool
+}
+
+// rewrite appends rewritten p to dst and returns the result.
+func (r *frameRewriter) rewrite(dst, p []byte) []byte {
+	for len(p) > 0 {
+		switch {
+		case r.skip > 0:
+			n := min(r.skip, len(p))
+			dst = append(dst, p[:n]...)
+			r.skip -= n
+			p = p[n:]
+		case r.payload > 0:
+			n := min(int(r.payload), len(p))
+			if !r.drop {
+				dst = append(dst, p[:n]...)
+			}
+			r.payload -= uint32(n)
+			p = p[n:]
+		default:
+			n := copy(r.head[r.headLen:r.wantHead()], p)
+			r.headLen += n
+			p = p[n:]
+			if r.headLen == r.wantHead() {
+				dst = r.flushHead(dst)
+			}
+		}
+	}
+	return dst
+}
+
+// wantHead returns number of bytes of the frame which are rewritten: frame header,
+// last stream ID of GOAWAY frame, and fields before header block of HEADERS frame if inject is pending.
+func (r *frameRewriter) wantHead() int {
+	if r.headLen < frameHeaderLen {
+		return frameHeaderLen
+	}
+	switch r.head[3] {
+	case goAwayFrameType:
+		if r.length() >= 4 {
+			return frameHeaderLen + 4
+		}
+	case headersFrameType:
+		if r.inject != nil {
+			n := frameHeaderLen
+			if r.head[4]&flagPadded != 0 {
+				n++
+			}
+			if r.head[4]&flagPriority != 0 {
+				n += 5
+			}
+			return n
+		}
+	}
+	return frameHeaderLen
+}
+
+// length returns payload length of the current frame.
+func (r *frameRewriter) length() uint32 {
+	return uint32(r.head[0])<<16 | uint32(r.head[1])<<8 | uint32(r.head[2])
+}
+
+// flushHead rewrites collected head of the frame and appends it to dst unless the frame is dropped.
+func (r *frameRewriter) flushHead(dst []byte) []byte {
+	head := r.head[:r.headLen]
+	r.headLen = 0
+	r.payload = r.length() - uint32(len(head)-frameHeaderLen)
+
+	var id uint32
+	id, keep := r.mapID(binary.BigEndian.Uint32(head[5:frameHeaderLen]) & (1<<31 - 1))
+	r.drop = !keep
+	if !keep {
+		return dst
+	}
+	binary.BigEndian.PutUint32(head[5:frameHeaderLen], id)
+	switch head[3] {
+	case goAwayFrameType:
+		if len(head) > frameHeaderLen {
+			last, ok := r.mapID(binary.BigEndian.Uint32(head[frameHeaderLen:]) & (1<<31 - 1))
+			if !ok {
+				last = 0
+			}
+			binary.BigEndian.PutUint32(head[frameHeaderLen:], last)
+		}
+	case headersFrameType:
+		if r.inject != nil {
+			length := r.length() + uint32(len(r.inject))
+			head[0], head[1], head[2] = byte(length>>16), byte(length>>8), byte(length)
+			dst = append(append(dst, head...), r.inject...)
+			r.inject = nil
+			return dst
+		}
+	}
+	return append(dst, head...)
+}
+
+// min returns the smaller of a and b.
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
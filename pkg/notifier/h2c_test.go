@@ -0,0 +1,190 @@
+package notifier
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestNotifier_H2CUpgrade(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	var upgrades, conns int32
+	h2Handler := h2c.NewHandler(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.ProtoMajor != 2 {
+			writer.WriteHeader(http.StatusHTTPVersionNotSupported)
+			return
+		}
+		if request.Method == http.MethodOptions {
+			atomic.AddInt32(&upgrades, 1)
+			writer.Header().Set("X-Upgrade", "accepted")
+			_, _ = writer.Write([]byte("upgrade response body"))
+			return
+		}
+		body, _ := ioutil.ReadAll(request.Body)
+		mu.Lock()
+		received = append(received, string(body))
+		mu.Unlock()
+		writer.Header().Set("X-Received", string(body))
+	}), &http2.Server{})
+	testSrv := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// Only Upgrade handshake is accepted, HTTP/2 with prior knowledge and HTTP/1.1 are rejected.
+		if request.Header.Get("Upgrade") != "h2c" {
+			writer.WriteHeader(http.StatusUpgradeRequired)
+			return
+		}
+		h2Handler.ServeHTTP(writer, request)
+	}))
+	testSrv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	testSrv.Start()
+	defer testSrv.Close()
+
+	params := &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   4,
+		H2CUpgrade:           true,
+	}
+	notifier := New(testSrv.URL, params)
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	_, err := notifier.Notify([]byte("first"))
+	require.NoError(t, err)
+	notifier.Wait()
+	_, err = notifier.Notify(generateTestMessages(4)...)
+	require.NoError(t, err)
+	notifier.Wait()
+
+	mu.Lock()
+	assert.ElementsMatch(t, []string{"first", "msg 0", "msg 1", "msg 2", "msg 3"}, received)
+	mu.Unlock()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&upgrades), "connection must be upgraded once")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&conns), "messages must be multiplexed over upgraded connection")
+
+	t.Run("Without upgrade", func(t *testing.T) {
+		p := *params
+		p.H2CUpgrade = false
+		notifier := New(testSrv.URL, &p)
+		failed := make(chan error, 1)
+		notifier.OnError(func(message []byte, err error) {
+			failed <- err
+		})
+		_, err := notifier.Notify([]byte("plain"))
+		require.NoError(t, err)
+		notifier.Wait()
+
+		var nErr *NotifyErr
+		require.True(t, errors.As(<-failed, &nErr))
+		assert.Equal(t, http.StatusUpgradeRequired, nErr.StatusCode)
+	})
+
+	t.Run("Upgrade refused", func(t *testing.T) {
+		plainSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+		defer plainSrv.Close()
+
+		notifier := New(plainSrv.URL, params)
+		failed := make(chan error, 1)
+		notifier.OnError(func(message []byte, err error) {
+			failed <- err
+		})
+		_, err := notifier.Notify([]byte("plain"))
+		require.NoError(t, err)
+		notifier.Wait()
+		assert.Contains(t, (<-failed).Error(), "h2c upgrade: server responded with status 200 OK")
+	})
+}
+
+func TestFrameRewriter(t *testing.T) {
+	var frames bytes.Buffer
+	framer := http2.NewFramer(&frames, nil)
+	require.NoError(t, framer.WriteSettings())
+	require.NoError(t, framer.WriteData(1, true, []byte("upgrade response")))
+	require.NoError(t, framer.WriteData(3, false, []byte("response")))
+	require.NoError(t, framer.WriteWindowUpdate(5, 10))
+	require.NoError(t, framer.WriteGoAway(5, http2.ErrCodeNo, []byte("debug")))
+
+	// Frames are split to single bytes to check rewriting across chunks.
+	r := frameRewriter{mapID: serverStreamID}
+	var rewritten []byte
+	for _, b := range frames.Bytes() {
+		rewritten = r.rewrite(rewritten, []byte{b})
+	}
+
+	reader := http2.NewFramer(nil, bytes.NewReader(rewritten))
+	f, err := reader.ReadFrame()
+	require.NoError(t, err)
+	assert.IsType(t, &http2.SettingsFrame{}, f)
+
+	f, err = reader.ReadFrame()
+	require.NoError(t, err)
+	require.IsType(t, &http2.DataFrame{}, f)
+	assert.Equal(t, uint32(1), f.Header().StreamID)
+	assert.Equal(t, []byte("response"), f.(*http2.DataFrame).Data())
+
+	f, err = reader.ReadFrame()
+	require.NoError(t, err)
+	require.IsType(t, &http2.WindowUpdateFrame{}, f)
+	assert.Equal(t, uint32(3), f.Header().StreamID)
+	assert.Equal(t, uint32(10), f.(*http2.WindowUpdateFrame).Increment)
+
+	f, err = reader.ReadFrame()
+	require.NoError(t, err)
+	require.IsType(t, &http2.GoAwayFrame{}, f)
+	assert.Equal(t, uint32(3), f.(*http2.GoAwayFrame).LastStreamID)
+	assert.Equal(t, []byte("debug"), f.(*http2.GoAwayFrame).DebugData())
+}
+
+func TestFrameRewriter_Client(t *testing.T) {
+	frames := bytes.NewBufferString(http2.ClientPreface)
+	framer := http2.NewFramer(frames, nil)
+	require.NoError(t, framer.WriteSettings())
+	require.NoError(t, framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      1,
+		BlockFragment: []byte("first"),
+		EndHeaders:    true,
+		Priority:      http2.PriorityParam{StreamDep: 0, Weight: 15},
+	}))
+	require.NoError(t, framer.WriteHeaders(http2.HeadersFrameParam{StreamID: 3, BlockFragment: []byte("second"), EndHeaders: true}))
+
+	r := frameRewriter{skip: len(http2.ClientPreface), mapID: clientStreamID, inject: restoreHeaderTableSize}
+	var rewritten []byte
+	for _, b := range frames.Bytes() {
+		rewritten = r.rewrite(rewritten, []byte{b})
+	}
+	require.Equal(t, http2.ClientPreface, string(rewritten[:len(http2.ClientPreface)]))
+
+	reader := http2.NewFramer(nil, bytes.NewReader(rewritten[len(http2.ClientPreface):]))
+	f, err := reader.ReadFrame()
+	require.NoError(t, err)
+	assert.IsType(t, &http2.SettingsFrame{}, f)
+
+	f, err = reader.ReadFrame()
+	require.NoError(t, err)
+	require.IsType(t, &http2.HeadersFrame{}, f)
+	assert.Equal(t, uint32(3), f.Header().StreamID)
+	assert.Equal(t, uint8(15), f.(*http2.HeadersFrame).Priority.Weight)
+	assert.Equal(t, append(append([]byte(nil), restoreHeaderTableSize...), "first"...), f.(*http2.HeadersFrame).HeaderBlockFragment())
+
+	f, err = reader.ReadFrame()
+	require.NoError(t, err)
+	require.IsType(t, &http2.HeadersFrame{}, f)
+	assert.Equal(t, uint32(5), f.Header().StreamID)
+	assert.Equal(t, []byte("second"), f.(*http2.HeadersFrame).HeaderBlockFragment(), "only the first block is changed")
+}
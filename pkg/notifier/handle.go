@@ -0,0 +1,51 @@
+package notifier
+
+import "sync/atomic"
+
+// HandleStats contains results of messages scheduled by NotifyHandle.
+type HandleStats struct {
+	// Pending is a number of messages which are not handled yet.
+	Pending int
+	// Succeeded is a number of delivered messages.
+	Succeeded int
+	// Failed is a number of messages which have not been delivered or scheduled.
+	Failed int
+}
+
+// Handle is a Batch which also tracks results of its messages.
+type Handle struct {
+	Batch
+
+	total     int
+	succeeded int64
+	failed    int64
+}
+
+// Stats returns current results of the handle messages.
+func (h *Handle) Stats() HandleStats {
+	succeeded := int(atomic.LoadInt64(&h.succeeded))
+	failed := int(atomic.LoadInt64(&h.failed))
+	return HandleStats{
+		Pending:   h.total - succeeded - failed,
+		Succeeded: succeeded,
+		Failed:    failed,
+	}
+}
+
+// record counts result of a message.
+func (h *Handle) record(err error) {
+	if err != nil {
+		atomic.AddInt64(&h.failed, 1)
+		return
+	}
+	atomic.AddInt64(&h.succeeded, 1)
+}
+
+// NotifyHandle works the same way as NotifyAsync, but returned Handle also provides statistics of the messages.
+// Handle is returned together with an error if some messages have not been scheduled.
+func (c *Client) NotifyHandle(messages ...[]byte) (*Handle, error) {
+	tasks := messageTasks(c.URL(), messages)
+	handle := &Handle{total: len(tasks)}
+	err := c.scheduleBatch(&handle.Batch, tasks, handle.record)
+	return handle, err
+}
@@ -0,0 +1,63 @@
+package notifier
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_NotifyHandle(t *testing.T) {
+	var canceled int32
+	started := make(chan struct{}, 2)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		if strings.HasPrefix(string(body), "slow") {
+			started <- struct{}{}
+			<-request.Context().Done()
+			atomic.AddInt32(&canceled, 1)
+		}
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   4,
+	})
+	delivered := make(chan struct{}, 2)
+	notifier.OnSuccess(func(message []byte, resp *http.Response) {
+		delivered <- struct{}{}
+	})
+
+	handle, err := notifier.NotifyHandle([]byte("fast 1"), []byte("slow 1"), []byte("fast 2"), []byte("slow 2"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, handle.Scheduled)
+	<-started
+	<-started
+	<-delivered
+	<-delivered
+
+	assert.Eventually(t, func() bool {
+		return handle.Stats() == HandleStats{Pending: 2, Succeeded: 2}
+	}, time.Second, 10*time.Millisecond)
+
+	handle.Cancel()
+	select {
+	case <-handle.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("handle should be done after cancel")
+	}
+	notifier.Wait()
+
+	assert.Equal(t, HandleStats{Succeeded: 2, Failed: 2}, handle.Stats())
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&canceled) == 2
+	}, time.Second, 10*time.Millisecond)
+}
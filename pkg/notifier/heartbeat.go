@@ -0,0 +1,45 @@
+package notifier
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Heartbeat starts sending payload to the Client URL whenever no message has been sent for the interval.
+// It keeps receivers which treat silence as a fault alive. Heartbeats stop when Client is stopped.
+// Heartbeat messages are sent the same way as regular messages and are subject to the same limits.
+// Calling Heartbeat again replaces the previous heartbeat.
+func (c *Client) Heartbeat(interval time.Duration, payload []byte) {
+	c.markSent()
+	ctx, cancel := context.WithCancel(c.context())
+	c.heartbeatMu.Lock()
+	if c.stopHeartbeat != nil {
+		c.stopHeartbeat()
+	}
+	c.stopHeartbeat = cancel
+	c.heartbeatMu.Unlock()
+	go func() {
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+			idle := time.Since(time.Unix(0, atomic.LoadInt64(&c.lastSent)))
+			if idle >= interval {
+				c.markSent()
+				_, _ = c.schedule([]task{{url: c.URL(), message: payload}})
+				idle = 0
+			}
+			timer.Reset(interval - idle)
+		}
+	}()
+}
+
+// markSent remembers time of the last sent message.
+func (c *Client) markSent() {
+	atomic.StoreInt64(&c.lastSent, time.Now().UnixNano())
+}
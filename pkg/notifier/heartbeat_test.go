@@ -0,0 +1,88 @@
+package notifier
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_Heartbeat(t *testing.T) {
+	var heartbeats, messages int32
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		if string(body) == "ping" {
+			atomic.AddInt32(&heartbeats, 1)
+			return
+		}
+		atomic.AddInt32(&messages, 1)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestsPerRate:   2,
+	})
+	const interval = 50 * time.Millisecond
+	notifier.Heartbeat(interval, []byte("ping"))
+
+	t.Run("Suppressed while traffic is active", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			_, err := notifier.Notify([]byte("message"))
+			require.NoError(t, err)
+			time.Sleep(interval / 5)
+		}
+		notifier.Wait()
+
+		assert.Equal(t, int32(10), atomic.LoadInt32(&messages))
+		assert.Equal(t, int32(0), atomic.LoadInt32(&heartbeats))
+	})
+
+	t.Run("Sent when idle", func(t *testing.T) {
+		time.Sleep(3 * interval)
+		notifier.Wait()
+
+		assert.GreaterOrEqual(t, atomic.LoadInt32(&heartbeats), int32(1))
+	})
+
+	t.Run("Stopped with Client", func(t *testing.T) {
+		notifier.Stop()
+		notifier.Wait()
+		sent := atomic.LoadInt32(&heartbeats)
+		time.Sleep(3 * interval)
+
+		assert.Equal(t, sent, atomic.LoadInt32(&heartbeats))
+	})
+}
+
+func TestNotifier_HeartbeatReplaced(t *testing.T) {
+	var first, second int32
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		if string(body) == "first" {
+			atomic.AddInt32(&first, 1)
+			return
+		}
+		atomic.AddInt32(&second, 1)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestsPerRate:   2,
+	})
+	defer notifier.Stop()
+	const interval = 20 * time.Millisecond
+	notifier.Heartbeat(interval, []byte("first"))
+	notifier.Heartbeat(interval, []byte("second"))
+	time.Sleep(5 * interval)
+	notifier.Wait()
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&first), "previous heartbeat must be stopped")
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&second), int32(1))
+}
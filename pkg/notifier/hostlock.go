@@ -0,0 +1,44 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+)
+
+// hostLocks allows only one in-flight request per host.
+type hostLocks struct {
+	mu    sync.Mutex
+	locks map[string]chan struct{}
+}
+
+// newHostLocks creates empty hostLocks.
+func newHostLocks() *hostLocks {
+	return &hostLocks{locks: make(map[string]chan struct{})}
+}
+
+// lock waits until there are no in-flight requests to the host and returns unlock func.
+// It returns an error if ctx is done while waiting.
+func (h *hostLocks) lock(ctx context.Context, host string) (func(), error) {
+	h.mu.Lock()
+	l, ok := h.locks[host]
+	if !ok {
+		l = make(chan struct{}, 1)
+		h.locks[host] = l
+	}
+	h.mu.Unlock()
+
+	select {
+	case l <- struct{}{}:
+		return func() { <-l }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// lockHost locks host if sends are serialized per host, otherwise it does nothing.
+func (c *Client) lockHost(ctx context.Context, host string) (func(), error) {
+	if c.hostLocks == nil {
+		return func() {}, nil
+	}
+	return c.hostLocks.lock(ctx, host)
+}
@@ -0,0 +1,62 @@
+package notifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// trackMax increments counter and updates max with its new value.
+func trackMax(counter, max *int32) {
+	current := atomic.AddInt32(counter, 1)
+	for {
+		old := atomic.LoadInt32(max)
+		if current <= old || atomic.CompareAndSwapInt32(max, old, current) {
+			return
+		}
+	}
+}
+
+func TestNotifier_SerializePerHost(t *testing.T) {
+	var total, maxTotal int32
+	newServer := func(inFlight, maxInFlight, received *int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			trackMax(inFlight, maxInFlight)
+			trackMax(&total, &maxTotal)
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&total, -1)
+			atomic.AddInt32(inFlight, -1)
+			atomic.AddInt32(received, 1)
+		}))
+	}
+	var inFlight1, max1, received1, inFlight2, max2, received2 int32
+	srv1 := newServer(&inFlight1, &max1, &received1)
+	defer srv1.Close()
+	srv2 := newServer(&inFlight2, &max2, &received2)
+	defer srv2.Close()
+
+	notifier := New(srv1.URL, &ClientParams{
+		MaxConcurrentWorkers: 8,
+		MaxRequestsPerRate:   8,
+		SerializePerHost:     true,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	_, err := notifier.NotifyTo(srv1.URL, generateTestMessages(4)...)
+	require.NoError(t, err)
+	_, err = notifier.NotifyTo(srv2.URL, generateTestMessages(4)...)
+	require.NoError(t, err)
+	notifier.Wait()
+
+	assert.Equal(t, int32(4), atomic.LoadInt32(&received1))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&received2))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&max1))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&max2))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxTotal), "hosts should be served concurrently")
+}
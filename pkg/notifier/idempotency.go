@@ -0,0 +1,20 @@
+package notifier
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// idempotencyKeyHeader contains idempotency key of the message if ClientParams.IdempotencyKeys is set.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// newIdempotencyKey returns random UUID version 4.
+func newIdempotencyKey() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("unable to generate idempotency key: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
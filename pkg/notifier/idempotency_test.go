@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var uuidRe = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNotifier_OnKeyedResult(t *testing.T) {
+	var mu sync.Mutex
+	sentKeys := make(map[string]int)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		key := request.Header.Get(idempotencyKeyHeader)
+		mu.Lock()
+		sentKeys[key]++
+		attempt := sentKeys[key]
+		mu.Unlock()
+		if attempt == 1 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.WriteHeader(http.StatusAccepted)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+		MaxRetries:           1,
+		IdempotencyKeys:      true,
+	})
+	results := make(map[string]int)
+	notifier.OnKeyedResult(func(key string, statusCode int, err error) {
+		assert.NoError(t, err)
+		mu.Lock()
+		results[key] = statusCode
+		mu.Unlock()
+	})
+	_, err := notifier.Notify(generateTestMessages(2)...)
+	require.NoError(t, err)
+	notifier.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, results, 2)
+	for key, statusCode := range results {
+		assert.Regexp(t, uuidRe, key)
+		assert.Equal(t, http.StatusAccepted, statusCode)
+		assert.Equal(t, 2, sentKeys[key], "retry should reuse idempotency key")
+	}
+}
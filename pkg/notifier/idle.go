@@ -0,0 +1,65 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+)
+
+// workGroup is a sync.WaitGroup which also allows to wait until its counter reaches zero
+// with a context and doesn't prevent adding new work after that.
+type workGroup struct {
+	sync.WaitGroup
+	mu    sync.Mutex
+	count int
+	idle  chan struct{} // closed when counter reaches zero, nil while counter is zero
+}
+
+// Add adds delta to the counter, see sync.WaitGroup.Add.
+func (w *workGroup) Add(delta int) {
+	w.WaitGroup.Add(delta)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.count += delta
+	switch {
+	case w.count > 0 && w.idle == nil:
+		w.idle = make(chan struct{})
+	case w.count == 0 && w.idle != nil:
+		close(w.idle)
+		w.idle = nil
+	}
+}
+
+// Done decrements the counter by one.
+func (w *workGroup) Done() {
+	w.Add(-1)
+}
+
+// busy reports if the counter is greater than zero.
+func (w *workGroup) busy() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.count > 0
+}
+
+// waitIdle blocks until the counter reaches zero or ctx is done.
+func (w *workGroup) waitIdle(ctx context.Context) error {
+	w.mu.Lock()
+	idle := w.idle
+	w.mu.Unlock()
+	if idle == nil {
+		return nil
+	}
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WaitIdle blocks until there are no in-flight or queued messages or ctx is done.
+// Unlike Wait, it may be called while messages are still being scheduled and Client remains usable afterwards.
+// It returns ctx error if ctx is done before Client becomes idle.
+func (c *Client) WaitIdle(ctx context.Context) error {
+	return c.workers.waitIdle(ctx)
+}
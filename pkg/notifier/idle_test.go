@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_WaitIdle(t *testing.T) {
+	var delivered int32
+	release := make(chan struct{})
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		<-release
+		atomic.AddInt32(&delivered, 1)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		MaxQueueDepth:        2,
+	})
+	defer notifier.Close() //nolint: errcheck
+
+	require.NoError(t, notifier.WaitIdle(context.Background()))
+
+	_, err := notifier.Notify(generateTestMessages(3)...)
+	var notifyErr *NotifyErr
+	require.True(t, errors.As(err, &notifyErr))
+	require.Equal(t, 2, notifyErr.Queued)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, notifier.WaitIdle(ctx))
+
+	close(release)
+	require.NoError(t, notifier.WaitIdle(context.Background()))
+	assert.EqualValues(t, 3, atomic.LoadInt32(&delivered))
+
+	n, err := notifier.Notify([]byte("after idle"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.NoError(t, notifier.WaitIdle(context.Background()))
+	assert.EqualValues(t, 4, atomic.LoadInt32(&delivered))
+}
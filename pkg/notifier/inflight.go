@@ -0,0 +1,85 @@
+package notifier
+
+import (
+	"fmt"
+	"sync"
+)
+
+// byteBudget limits total size of messages which have been scheduled but not finished yet.
+type byteBudget struct {
+	mu    sync.Mutex
+	limit int
+	used  int
+}
+
+// acquire reserves size bytes and reports if they fit into the budget.
+func (b *byteBudget) acquire(size int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.used+size > b.limit {
+		return false
+	}
+	b.used += size
+	return true
+}
+
+// release returns size bytes to the budget.
+func (b *byteBudget) release(size int) {
+	b.mu.Lock()
+	b.used -= size
+	b.mu.Unlock()
+}
+
+// inUse returns number of reserved bytes.
+func (b *byteBudget) inUse() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// InFlightBytes returns size of messages which are being sent or waiting in the overflow queue.
+// Sizes are measured by ClientParams.Sizer. It's always zero if ClientParams.MaxInFlightBytes is not set.
+func (c *Client) InFlightBytes() int {
+	if c.inFlight == nil {
+		return 0
+	}
+	return c.inFlight.inUse()
+}
+
+// reserveBytes reserves budget for every task until it's done. It returns tasks which fit into the budget,
+// the rest are reported as failed. Bodies sent by NotifyReaders are not accounted, because their size is unknown.
+func (c *Client) reserveBytes(tasks []task) []task {
+	if c.inFlight == nil {
+		return tasks
+	}
+	reserved := make([]task, 0, len(tasks))
+	for _, t := range tasks {
+		if t.reader != nil {
+			reserved = append(reserved, t)
+			continue
+		}
+		size := c.sizer(t.message)
+		if !c.inFlight.acquire(size) {
+			err := &NotifyErr{
+				Type:    TypeWorkersLimitExceeded,
+				Message: "In-flight bytes limit exceeded",
+				Err:     fmt.Errorf("message size %d doesn't fit into %d bytes budget", size, c.inFlight.limit),
+			}
+			c.metrics.recordRejected(t.count())
+			c.notifyError(t.message, err)
+			if t.done != nil {
+				t.done(err)
+			}
+			continue
+		}
+		done := t.done
+		t.done = func(err error) {
+			c.inFlight.release(size)
+			if done != nil {
+				done(err)
+			}
+		}
+		reserved = append(reserved, t)
+	}
+	return reserved
+}
@@ -0,0 +1,57 @@
+package notifier
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_MaxInFlightBytes(t *testing.T) {
+	release := make(chan struct{})
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = ioutil.ReadAll(request.Body)
+		<-release
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   4,
+		MaxInFlightBytes:     10,
+		Sizer: func(message []byte) int {
+			return 2 * len(message)
+		},
+	})
+	failed := make(chan error, 1)
+	notifier.OnError(func(message []byte, err error) {
+		assert.Equal(t, "c", string(message))
+		failed <- err
+	})
+
+	_, err := notifier.Notify([]byte("abcd"))
+	require.NoError(t, err)
+	assert.Equal(t, 8, notifier.InFlightBytes())
+	_, err = notifier.Notify([]byte("b"))
+	require.NoError(t, err)
+	assert.Equal(t, 10, notifier.InFlightBytes())
+
+	// Message of 1 byte costs 2 bytes of the budget, so it doesn't fit.
+	n, err := notifier.Notify([]byte("c"))
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	var nErr *NotifyErr
+	require.True(t, errors.As(<-failed, &nErr))
+	assert.Equal(t, TypeWorkersLimitExceeded, nErr.Type)
+	assert.Equal(t, 10, notifier.InFlightBytes())
+
+	close(release)
+	notifier.Wait()
+	assert.Zero(t, notifier.InFlightBytes())
+}
@@ -0,0 +1,21 @@
+package notifier
+
+import "net/http"
+
+// Item is a message with its own headers.
+type Item struct {
+	Body []byte
+	// Headers are set to the request over ClientParams.Headers, replacing values of the same keys.
+	Headers http.Header
+}
+
+// NotifyItems works the same way as Notify, but every message is sent with headers of its Item.
+// Items are never deduplicated or coalesced, because the same body may be sent with different headers.
+// NotifyErr.Remaining contains bodies of items which have not been scheduled.
+func (c *Client) NotifyItems(items ...Item) (int, error) {
+	tasks := make([]task, len(items))
+	for i, item := range items {
+		tasks[i] = task{url: c.URL(), message: item.Body, headers: item.Headers.Clone()}
+	}
+	return c.schedule(tasks)
+}
@@ -0,0 +1,50 @@
+package notifier
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_NotifyItems(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string]http.Header)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		mu.Lock()
+		received[string(body)] = request.Header.Clone()
+		mu.Unlock()
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 3,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   3,
+		Headers:              http.Header{"X-Type": {"default"}, "X-Source": {"test"}},
+	})
+	n, err := notifier.NotifyItems(
+		Item{Body: []byte("order"), Headers: http.Header{"X-Type": {"order"}}},
+		Item{Body: []byte("refund"), Headers: http.Header{"X-Type": {"refund"}}},
+		Item{Body: []byte("plain")},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	notifier.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 3)
+	assert.Equal(t, []string{"order"}, received["order"].Values("X-Type"))
+	assert.Equal(t, []string{"refund"}, received["refund"].Values("X-Type"))
+	assert.Equal(t, []string{"default"}, received["plain"].Values("X-Type"))
+	for body, header := range received {
+		assert.Equal(t, "test", header.Get("X-Source"), "client headers should be kept for %s", body)
+	}
+}
@@ -0,0 +1,36 @@
+package notifier
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// jitterSource is a source of backoff jitter which is safe for concurrent use.
+type jitterSource struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// newJitterSource creates jitterSource using r or a source seeded by current time if r is nil.
+func newJitterSource(r *rand.Rand) *jitterSource {
+	if r == nil {
+		r = rand.New(rand.NewSource(time.Now().UnixNano())) //nolint: gosec
+	}
+	return &jitterSource{rand: r}
+}
+
+// float64 returns pseudo-random number in [0.0,1.0).
+func (s *jitterSource) float64() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rand.Float64()
+}
+
+// jitter randomly shortens delay by up to RetryJitter part of it.
+func (c *Client) jitter(delay time.Duration) time.Duration {
+	if c.retryJitter <= 0 || delay <= 0 {
+		return delay
+	}
+	return delay - time.Duration(c.jitterSource.float64()*c.retryJitter*float64(delay))
+}
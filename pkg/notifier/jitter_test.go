@@ -0,0 +1,48 @@
+package notifier
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifier_RetryJitter(t *testing.T) {
+	const (
+		seed    = 42
+		backoff = 100 * time.Millisecond
+		jitter  = 0.5
+	)
+	delays := func(notifier *Client) []time.Duration {
+		var d []time.Duration
+		for attempt := 0; attempt < 5; attempt++ {
+			d = append(d, notifier.backoffDelay(attempt))
+		}
+		return d
+	}
+
+	first := delays(New("http://localhost", &ClientParams{
+		MaxConcurrentWorkers: 1,
+		RetryBackoff:         backoff,
+		RetryJitter:          jitter,
+		Rand:                 rand.New(rand.NewSource(seed)),
+	}))
+	second := delays(NewWithOptions("http://localhost",
+		WithParams(&ClientParams{MaxConcurrentWorkers: 1, RetryBackoff: backoff, RetryJitter: jitter}),
+		WithRand(rand.New(rand.NewSource(seed))),
+	))
+	assert.Equal(t, first, second, "delays must be reproducible with the same seed")
+
+	r := rand.New(rand.NewSource(seed))
+	for attempt, delay := range first {
+		full := backoff << uint(attempt)
+		assert.Equal(t, full-time.Duration(r.Float64()*jitter*float64(full)), delay)
+		assert.True(t, delay > full/2 && delay <= full, "delay %v of attempt %d is out of range", delay, attempt)
+	}
+
+	t.Run("Without jitter", func(t *testing.T) {
+		notifier := New("http://localhost", &ClientParams{MaxConcurrentWorkers: 1, RetryBackoff: backoff})
+		assert.Equal(t, []time.Duration{backoff, 2 * backoff, 4 * backoff, 8 * backoff, 16 * backoff}, delays(notifier))
+	})
+}
@@ -0,0 +1,13 @@
+package notifier
+
+// EnterMaintenance holds sending of messages until ExitMaintenance is called.
+// Scheduled workers wait before sending, so messages are queued rather than failed.
+// Keep in mind that waiting workers occupy workers limit, so use ClientParams.BlockWhenFull to queue more messages.
+func (c *Client) EnterMaintenance() {
+	c.maintenance.close()
+}
+
+// ExitMaintenance resumes sending of held messages.
+func (c *Client) ExitMaintenance() {
+	c.maintenance.open()
+}
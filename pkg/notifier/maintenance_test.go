@@ -0,0 +1,48 @@
+package notifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_Maintenance(t *testing.T) {
+	var received int32
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&received, 1)
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+		BlockWhenFull:        true,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+
+	notifier.EnterMaintenance()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		n, err := notifier.Notify(generateTestMessages(5)...)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, n)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Zero(t, atomic.LoadInt32(&received), "messages must not be sent during maintenance")
+
+	notifier.ExitMaintenance()
+	<-done
+	notifier.Wait()
+	require.Equal(t, int32(5), atomic.LoadInt32(&received))
+}
@@ -0,0 +1,141 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// metrics collects delivery counters of the Client. All counters are updated atomically.
+type metrics struct {
+	scheduled    uint64
+	succeeded    uint64
+	failed       uint64
+	failedByType [TypeDraining + 1]uint64
+	rejected     uint64
+	retried      uint64
+}
+
+// Stats contains delivery counters of the Client since it has been created.
+type Stats struct {
+	// Scheduled is a number of messages which have got a worker.
+	Scheduled uint64
+	// Succeeded is a number of delivered messages.
+	Succeeded uint64
+	// Failed is a number of scheduled messages which have not been delivered.
+	Failed uint64
+	// FailedByType splits Failed by type of NotifyErr. Types without failures are omitted.
+	FailedByType map[ErrorType]uint64
+	// Rejected is a number of messages which have never got a worker, e.g. because workers limit is exceeded,
+	// message is invalid or Client has been stopped. Queued messages are not rejected until they are dropped.
+	Rejected uint64
+	// Retried is a number of retried send attempts.
+	Retried uint64
+}
+
+// Stats returns current delivery counters. It's safe for concurrent use.
+func (c *Client) Stats() Stats {
+	s := Stats{
+		Scheduled:    atomic.LoadUint64(&c.metrics.scheduled),
+		Succeeded:    atomic.LoadUint64(&c.metrics.succeeded),
+		Failed:       atomic.LoadUint64(&c.metrics.failed),
+		FailedByType: make(map[ErrorType]uint64),
+		Rejected:     atomic.LoadUint64(&c.metrics.rejected),
+		Retried:      atomic.LoadUint64(&c.metrics.retried),
+	}
+	for errType := range c.metrics.failedByType {
+		if n := atomic.LoadUint64(&c.metrics.failedByType[errType]); n > 0 {
+			s.FailedByType[ErrorType(errType)] = n
+		}
+	}
+	return s
+}
+
+// recordFailed counts message which has not been delivered by a worker.
+func (m *metrics) recordFailed(err error) {
+	atomic.AddUint64(&m.failed, 1)
+	var notifyErr *NotifyErr
+	if errors.As(err, &notifyErr) && notifyErr.Type >= 0 && int(notifyErr.Type) < len(m.failedByType) {
+		atomic.AddUint64(&m.failedByType[notifyErr.Type], 1)
+	}
+}
+
+// recordRejected counts n messages which have never got a worker.
+func (m *metrics) recordRejected(n int) {
+	atomic.AddUint64(&m.rejected, uint64(n))
+}
+
+// pushClient is used by PushMetrics. It doesn't share the transport of the Client, so credentials,
+// TransportWrapper and certificate pinning of the notification endpoint are not applied to Pushgateway.
+var pushClient = &http.Client{Timeout: 30 * time.Second}
+
+// PushMetrics pushes collected metrics to Prometheus Pushgateway at pushgatewayURL using job name.
+// It is intended for short-lived jobs which can't be scraped, so it's usually called after Wait.
+// Metrics of the job are replaced on every push. The push is canceled when ctx is done
+// and takes at most 30 seconds anyway.
+func (c *Client) PushMetrics(ctx context.Context, pushgatewayURL, job string) error {
+	if job == "" {
+		return fmt.Errorf("job name must not be empty")
+	}
+	target := strings.TrimSuffix(pushgatewayURL, "/") + "/metrics/job/" + url.PathEscape(job)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(c.prometheusMetrics()))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := pushClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to push metrics: %w", err)
+	}
+	defer resp.Body.Close() //nolint: errcheck
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unable to push metrics, unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// prometheusMetrics returns metrics in Prometheus text exposition format.
+func (c *Client) prometheusMetrics() []byte {
+	var buf bytes.Buffer
+	write := func(name, kind, help string, value uint64) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+	}
+	write("notifier_messages_scheduled_total", "counter", "Number of scheduled messages.",
+		atomic.LoadUint64(&c.metrics.scheduled))
+	write("notifier_messages_succeeded_total", "counter", "Number of delivered messages.",
+		atomic.LoadUint64(&c.metrics.succeeded))
+	write("notifier_messages_failed_total", "counter", "Number of messages which have not been delivered.",
+		atomic.LoadUint64(&c.metrics.failed))
+	write("notifier_messages_rejected_total", "counter", "Number of messages which have never got a worker.",
+		atomic.LoadUint64(&c.metrics.rejected))
+	write("notifier_retries_total", "counter", "Number of retried send attempts.",
+		atomic.LoadUint64(&c.metrics.retried))
+	write("notifier_workers_in_flight", "gauge", "Number of currently running workers.", uint64(c.InFlight()))
+	write("notifier_workers_limit", "gauge", "Maximum number of concurrent workers.", uint64(c.Capacity()))
+	return buf.Bytes()
+}
+
+// InFlight returns number of occupied worker slots. It's safe for concurrent use.
+// Workers are occupied by messages which are being sent, including waiting for retries and the rate.
+func (c *Client) InFlight() int {
+	if c.tuner != nil {
+		return c.tuner.inUse()
+	}
+	return len(c.workersLimiter)
+}
+
+// Capacity returns current workers limit. It's safe for concurrent use.
+// It may be less than ClientParams.MaxConcurrentWorkers if the limit is shrunk by auto-tuning.
+func (c *Client) Capacity() int {
+	if c.tuner != nil {
+		return c.tuner.limit()
+	}
+	return cap(c.workersLimiter)
+}
@@ -0,0 +1,174 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_PushMetrics(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		if string(body) == "fail" {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	type push struct {
+		method, path, contentType, authorization, body string
+	}
+	pushes := make(chan push, 1)
+	pushgateway := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		pushes <- push{
+			method:        request.Method,
+			path:          request.URL.Path,
+			contentType:   request.Header.Get("Content-Type"),
+			authorization: request.Header.Get("Authorization"),
+			body:          string(body),
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer pushgateway.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 5,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   5,
+		MaxRetries:           1,
+		TransportWrapper: func(next http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				req.Header.Set("Authorization", "Bearer secret")
+				return next.RoundTrip(req)
+			})
+		},
+	})
+	_, err := notifier.Notify([]byte("ok 1"), []byte("ok 2"), []byte("fail"))
+	require.NoError(t, err)
+	notifier.Wait()
+
+	require.NoError(t, notifier.PushMetrics(context.Background(), pushgateway.URL, "batch job"))
+
+	p := <-pushes
+	assert.Empty(t, p.authorization, "transport of the Client must not be used for pushing")
+	assert.Equal(t, http.MethodPut, p.method)
+	assert.Equal(t, "/metrics/job/batch job", p.path)
+	assert.Equal(t, "text/plain; version=0.0.4", p.contentType)
+	assert.Contains(t, p.body, "# TYPE notifier_messages_scheduled_total counter\nnotifier_messages_scheduled_total 3\n")
+	assert.Contains(t, p.body, "notifier_messages_succeeded_total 2\n")
+	assert.Contains(t, p.body, "notifier_messages_failed_total 1\n")
+	assert.Contains(t, p.body, "notifier_retries_total 1\n")
+	assert.Contains(t, p.body, "# TYPE notifier_workers_limit gauge\nnotifier_workers_limit 5\n")
+}
+
+func TestNotifier_PushMetricsError(t *testing.T) {
+	pushgateway := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusBadRequest)
+	}))
+	defer pushgateway.Close()
+
+	notifier := New("", nil)
+
+	assert.Error(t, notifier.PushMetrics(context.Background(), pushgateway.URL, "job"))
+	assert.Error(t, notifier.PushMetrics(context.Background(), pushgateway.URL, ""))
+
+	t.Run("Context canceled", func(t *testing.T) {
+		release := make(chan struct{})
+		slowGateway := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			<-release
+		}))
+		defer slowGateway.Close()
+		defer close(release)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := notifier.PushMetrics(ctx, slowGateway.URL, "job")
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+}
+
+func TestNotifier_InFlight(t *testing.T) {
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = ioutil.ReadAll(request.Body)
+		started <- struct{}{}
+		<-release
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 5,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   5,
+	})
+	assert.Equal(t, 5, notifier.Capacity())
+	assert.Zero(t, notifier.InFlight())
+
+	_, err := notifier.Notify(generateTestMessages(3)...)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+	assert.Equal(t, 3, notifier.InFlight())
+
+	close(release)
+	notifier.Wait()
+	assert.Zero(t, notifier.InFlight())
+	assert.Equal(t, 5, notifier.Capacity())
+}
+
+func TestNotifier_Stats(t *testing.T) {
+	release := make(chan struct{})
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		switch string(body) {
+		case "fail":
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		case "slow":
+			<-release
+		}
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   4,
+		MaxRetries:           1,
+		MaxMessageSize:       10,
+		RequestTimeout:       100 * time.Millisecond,
+	})
+	assert.Equal(t, Stats{FailedByType: map[ErrorType]uint64{}}, notifier.Stats())
+
+	_, err := notifier.Notify([]byte("ok 1"), []byte("ok 2"), []byte("fail"), []byte("slow"))
+	require.NoError(t, err)
+	_, err = notifier.Notify([]byte("too large message"), []byte("busy"))
+	var nErr *NotifyErr
+	require.True(t, errors.As(err, &nErr))
+	assert.Equal(t, TypeWorkersLimitExceeded, nErr.Type)
+	notifier.Wait()
+	close(release)
+
+	assert.Equal(t, Stats{
+		Scheduled: 4,
+		Succeeded: 2,
+		Failed:    2,
+		FailedByType: map[ErrorType]uint64{
+			TypeSendError: 1,
+			TypeTimeout:   1,
+		},
+		Rejected: 2,
+		Retried:  1,
+	}, notifier.Stats())
+}
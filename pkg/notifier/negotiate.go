@@ -0,0 +1,57 @@
+package notifier
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ResponseDecoder decodes body of successful response into a structured acknowledgement.
+type ResponseDecoder func(body []byte) (interface{}, error)
+
+// decodeResponse decodes body of successful response using decoder registered for its media type.
+// It returns nil if there is no such decoder.
+func (c *Client) decodeResponse(resp *http.Response, body []byte) (interface{}, error) {
+	if len(c.decoders) == 0 {
+		return nil, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, nil
+	}
+	decode, ok := c.decoders[mediaType]
+	if !ok {
+		return nil, nil
+	}
+	ack, err := decode(body)
+	if err != nil {
+		return nil, &NotifyErr{
+			Type:       TypeSendError,
+			Message:    msgSendErrorResponse,
+			Err:        fmt.Errorf("unable to decode %s response: %w", mediaType, err),
+			StatusCode: resp.StatusCode,
+		}
+	}
+	return ack, nil
+}
+
+// newDecoders returns copy of decoders with lowercase media types.
+func newDecoders(decoders map[string]ResponseDecoder) map[string]ResponseDecoder {
+	if len(decoders) == 0 {
+		return nil
+	}
+	m := make(map[string]ResponseDecoder, len(decoders))
+	for mediaType, decode := range decoders {
+		m[strings.ToLower(mediaType)] = decode
+	}
+	return m
+}
+
+// OnAck sets handler which receives acknowledgement of the delivered message decoded by ClientParams.ResponseDecoders.
+// It isn't called if response has no decoder for its Content-Type. It is called before the handler set by OnSuccess.
+func (c *Client) OnAck(handler func(message []byte, ack interface{})) {
+	if handler != nil {
+		c.ack = handler
+	}
+}
@@ -0,0 +1,26 @@
+// Package oauth provides OAuth2 client credentials flow support for the notifier library.
+//
+// It is kept in a separate package to keep OAuth2 dependency out of the core library.
+package oauth
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// ClientCredentials returns transport wrapper which can be used as notifier.ClientParams.TransportWrapper.
+// Every request gets "Authorization: Bearer" header with token fetched from cfg.TokenURL.
+// Token is cached and refreshed automatically when it expires.
+// Token requests use the same underlying transport as notifications.
+func ClientCredentials(cfg *clientcredentials.Config) func(http.RoundTripper) http.RoundTripper {
+	return func(base http.RoundTripper) http.RoundTripper {
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: base})
+		return &oauth2.Transport{
+			Source: cfg.TokenSource(ctx),
+			Base:   base,
+		}
+	}
+}
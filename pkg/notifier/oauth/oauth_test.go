@@ -0,0 +1,66 @@
+package oauth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/idexter/notifier-test-task/pkg/notifier"
+)
+
+func TestClientCredentials(t *testing.T) {
+	var issued int32
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		user, password, ok := request.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "client", user)
+		assert.Equal(t, "secret", password)
+		assert.NoError(t, request.ParseForm())
+		assert.Equal(t, "client_credentials", request.PostForm.Get("grant_type"))
+		assert.Equal(t, "notify", request.PostForm.Get("scope"))
+
+		n := atomic.AddInt32(&issued, 1)
+		writer.Header().Set("Content-Type", "application/json")
+		// Token expires immediately, so every request must refresh it.
+		_, _ = fmt.Fprintf(writer, `{"access_token":"token-%d","token_type":"bearer","expires_in":1}`, n)
+	}))
+	defer tokenSrv.Close()
+
+	tokens := make(chan string, 2)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		tokens <- request.Header.Get("Authorization")
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	client := notifier.New(testSrv.URL, &notifier.ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		TransportWrapper: ClientCredentials(&clientcredentials.Config{
+			ClientID:     "client",
+			ClientSecret: "secret",
+			TokenURL:     tokenSrv.URL,
+			Scopes:       []string{"notify"},
+		}),
+	})
+	client.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	for i := 0; i < 2; i++ {
+		_, err := client.Notify([]byte("test message"))
+		require.NoError(t, err)
+		client.Wait()
+	}
+
+	assert.Equal(t, "Bearer token-1", <-tokens)
+	assert.Equal(t, "Bearer token-2", <-tokens)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&issued))
+}
@@ -0,0 +1,80 @@
+package notifier
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// defaultOfflineProbeInterval is used if ClientParams.OfflineProbeInterval is not set.
+const defaultOfflineProbeInterval = 5 * time.Second
+
+// offlineDetector switches Client to offline mode after a number of consecutive connection failures.
+// While Client is offline messages wait instead of being sent and the server is probed periodically.
+// The first successful probe switches Client back online, so waiting messages are sent.
+type offlineDetector struct {
+	gate
+
+	threshold int32
+	failures  int32
+	interval  time.Duration
+	probe     func() bool
+	// ctx returns current Client context, probing stops when it is done.
+	ctx func() context.Context
+}
+
+// failed records connection failure and reports if Client is offline.
+func (o *offlineDetector) failed() bool {
+	if o.isClosed() {
+		return true
+	}
+	if atomic.AddInt32(&o.failures, 1) < o.threshold {
+		return false
+	}
+	if o.close() {
+		go o.probeUntilOnline(o.ctx())
+	}
+	return true
+}
+
+// succeeded resets number of consecutive failures.
+func (o *offlineDetector) succeeded() {
+	atomic.StoreInt32(&o.failures, 0)
+}
+
+// probeUntilOnline probes the server every interval until a probe succeeds or ctx is done.
+func (o *offlineDetector) probeUntilOnline(ctx context.Context) {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if o.probe() {
+				o.succeeded()
+				o.open()
+				return
+			}
+		}
+	}
+}
+
+// probe sends HEAD request to the probe URL and reports if the server has responded with any status.
+func (c *Client) probe() bool {
+	url := c.offlineProbeURL
+	if url == "" {
+		url = c.URL()
+	}
+	req, err := http.NewRequestWithContext(c.context(), http.MethodHead, url, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false
+	}
+	closeResponse(resp)
+	return true
+}
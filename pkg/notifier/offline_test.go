@@ -0,0 +1,68 @@
+package notifier
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_Offline(t *testing.T) {
+	// Reserve an address and release it, so the server is unreachable until it's started on the address.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	var mu sync.Mutex
+	var received []string
+	var probes int32
+	testSrv := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method == http.MethodHead {
+			atomic.AddInt32(&probes, 1)
+			return
+		}
+		body, _ := ioutil.ReadAll(request.Body)
+		mu.Lock()
+		received = append(received, string(body))
+		mu.Unlock()
+	}))
+
+	notifier := New("http://"+addr, &ClientParams{
+		MaxConcurrentWorkers: 3,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   3,
+		OfflineAfterFailures: 1,
+		OfflineProbeInterval: 20 * time.Millisecond,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	_, err = notifier.Notify([]byte("1"), []byte("2"), []byte("3"))
+	require.NoError(t, err)
+
+	require.Eventually(t, notifier.offline.isClosed, time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+
+	testSrv.Listener, err = net.Listen("tcp", addr)
+	require.NoError(t, err)
+	testSrv.Start()
+	defer testSrv.Close()
+
+	notifier.Wait()
+	assert.False(t, notifier.offline.isClosed())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&probes))
+
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(received)
+	assert.Equal(t, []string{"1", "2", "3"}, received)
+}
@@ -0,0 +1,115 @@
+package notifier
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Option configures Client created by NewWithOptions.
+type Option func(*config)
+
+// config collects options of NewWithOptions.
+type config struct {
+	params ClientParams
+	// optimalWorkers makes workers limit calculated like for nil params of New.
+	optimalWorkers bool
+}
+
+// NewWithOptions creates new notification Client configured by opts.
+// Options are applied in order on top of DefaultParams, so later options override earlier ones.
+func NewWithOptions(url string, opts ...Option) *Client {
+	cfg := config{params: *DefaultParams, optimalWorkers: true}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var transport http.RoundTripper
+	if cfg.params.HTTPClient != nil {
+		transport = cfg.params.HTTPClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+	} else {
+		transport = newTransport(&cfg.params)
+	}
+	if cfg.optimalWorkers {
+		cfg.params.MaxConcurrentWorkers = calculateOptimalWorkersLimit(transport)
+	}
+
+	n := create(url, &cfg.params, transport)
+	if cfg.params.HTTPClient != nil {
+		// Transport of the Client is kept, so TransportWrapper is applied to the custom client too.
+		client := *cfg.params.HTTPClient
+		client.Transport = n.client.Transport
+		n.client = &client
+	}
+	return n
+}
+
+// WithParams replaces all params set by previous options with a copy of params.
+// Nil params are ignored.
+func WithParams(params *ClientParams) Option {
+	return func(c *config) {
+		if params == nil {
+			return
+		}
+		c.params = *params
+		c.params.Headers = params.Headers.Clone()
+		c.optimalWorkers = false
+	}
+}
+
+// WithMaxWorkers sets a number of concurrent workers, see ClientParams.MaxConcurrentWorkers.
+func WithMaxWorkers(workers uint64) Option {
+	return func(c *config) {
+		c.params.MaxConcurrentWorkers = workers
+		c.optimalWorkers = false
+	}
+}
+
+// WithRate sets rate limit of requests, see ClientParams.MaxRequestRate and ClientParams.MaxRequestsPerRate.
+func WithRate(requests int, interval time.Duration) Option {
+	return func(c *config) {
+		c.params.MaxRequestsPerRate = requests
+		c.params.MaxRequestRate = interval
+	}
+}
+
+// WithHeader adds header to every request, see ClientParams.Headers.
+func WithHeader(key, value string) Option {
+	return func(c *config) {
+		if c.params.Headers == nil {
+			c.params.Headers = make(http.Header)
+		}
+		c.params.Headers.Add(key, value)
+	}
+}
+
+// WithRetries sets a number of additional attempts, see ClientParams.MaxRetries.
+func WithRetries(retries int) Option {
+	return func(c *config) {
+		c.params.MaxRetries = retries
+	}
+}
+
+// WithRequestTimeout sets time limit of every message, see ClientParams.RequestTimeout.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.params.RequestTimeout = timeout
+	}
+}
+
+// WithRand sets source of retry jitter randomness, see ClientParams.Rand.
+func WithRand(r *rand.Rand) Option {
+	return func(c *config) {
+		c.params.Rand = r
+	}
+}
+
+// WithHTTPClient makes Client send requests using client, see ClientParams.HTTPClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		c.params.HTTPClient = client
+	}
+}
@@ -0,0 +1,80 @@
+package notifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		notifier := NewWithOptions("http://localhost")
+		expected := New("http://localhost", nil)
+		assert.Equal(t, cap(expected.workersLimiter), cap(notifier.workersLimiter))
+		assert.Equal(t, expected.requestsLimiter.Limit(), notifier.requestsLimiter.Limit())
+		assert.Equal(t, expected.requestsLimiter.Burst(), notifier.requestsLimiter.Burst())
+		assert.Equal(t, expected.client.Transport, notifier.client.Transport)
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		notifier := NewWithOptions("http://localhost",
+			WithMaxWorkers(3),
+			WithRate(5, time.Second),
+			WithRetries(2),
+			WithRequestTimeout(time.Second),
+			WithHeader("X-Tenant", "a"),
+			WithHeader("X-Tenant", "b"),
+		)
+		assert.Equal(t, 3, cap(notifier.workersLimiter))
+		assert.Equal(t, rate.Every(time.Second), notifier.requestsLimiter.Limit())
+		assert.Equal(t, 5, notifier.requestsLimiter.Burst())
+		assert.Equal(t, 2, notifier.maxRetries)
+		assert.Equal(t, time.Second, notifier.requestTimeout)
+		assert.Equal(t, []string{"a", "b"}, notifier.headers.Values("X-Tenant"))
+	})
+
+	t.Run("Options override params", func(t *testing.T) {
+		params := &ClientParams{
+			MaxConcurrentWorkers: 1,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   1,
+			Headers:              http.Header{"X-Tenant": {"a"}},
+		}
+		notifier := NewWithOptions("http://localhost", WithParams(params), WithMaxWorkers(4), WithHeader("X-Tenant", "b"))
+		assert.Equal(t, 4, cap(notifier.workersLimiter))
+		assert.Equal(t, []string{"a", "b"}, notifier.headers.Values("X-Tenant"))
+		assert.Equal(t, []string{"a"}, params.Headers.Values("X-Tenant"), "params must not be changed")
+
+		notifier = NewWithOptions("http://localhost", WithMaxWorkers(4), WithParams(params))
+		assert.Equal(t, 1, cap(notifier.workersLimiter))
+	})
+
+	t.Run("HTTP client", func(t *testing.T) {
+		headers := make(chan http.Header, 1)
+		testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			headers <- request.Header
+		}))
+		defer testSrv.Close()
+
+		httpClient := &http.Client{Timeout: time.Minute}
+		notifier := NewWithOptions(testSrv.URL,
+			WithHTTPClient(httpClient),
+			WithMaxWorkers(1),
+			WithRate(1, time.Millisecond),
+			WithHeader("X-Tenant", "a"),
+		)
+		assert.Equal(t, time.Minute, notifier.client.Timeout)
+		notifier.OnError(func(message []byte, err error) {
+			t.Errorf("unexpected error: %v", err)
+		})
+		_, err := notifier.Notify([]byte("message"))
+		require.NoError(t, err)
+		notifier.Wait()
+		assert.Equal(t, "a", (<-headers).Get("X-Tenant"))
+	})
+}
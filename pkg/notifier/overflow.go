@@ -0,0 +1,54 @@
+package notifier
+
+// OverflowPolicy defines what Notify does with messages when workers limit and overflow queue are full.
+type OverflowPolicy int
+
+const (
+	// OverflowReject returns TypeWorkersLimitExceeded error with messages which have not been scheduled.
+	OverflowReject OverflowPolicy = iota
+	// OverflowBlock blocks Notify until a worker is free.
+	OverflowBlock
+	// OverflowDropOldest evicts the oldest message from the overflow queue to make room for the new one.
+	// It requires ClientParams.MaxQueueDepth.
+	OverflowDropOldest
+	// OverflowDropNewest drops new messages which don't fit into the overflow queue.
+	OverflowDropNewest
+)
+
+// drop puts tasks to the overflow queue according to drop policy and returns number of handled messages.
+// Dropped messages are reported with TypeWorkersLimitExceeded error.
+func (c *Client) drop(tasks []task) int {
+	var n int
+	hold := func() { c.workers.Add(1) }
+	for _, t := range tasks {
+		n += t.count()
+		if c.queue == nil || t.reader != nil {
+			c.cancelTask(t, newDropErr())
+			continue
+		}
+		if c.overflowPolicy == OverflowDropOldest {
+			evicted, ok := c.queue.evictAndEnqueue(t, hold)
+			if evicted != nil {
+				c.cancelTask(*evicted, newDropErr())
+				c.workers.Done()
+			}
+			if !ok {
+				c.cancelTask(t, newDropErr())
+			}
+			continue
+		}
+		if !c.queue.enqueue(t, hold) {
+			c.cancelTask(t, newDropErr())
+		}
+	}
+	return n
+}
+
+// newDropErr returns error for messages dropped by overflow policy.
+func newDropErr() *NotifyErr {
+	return &NotifyErr{
+		Type:    TypeWorkersLimitExceeded,
+		Message: "Message dropped, workers limit exceeded",
+		Err:     nil,
+	}
+}
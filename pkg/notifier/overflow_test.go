@@ -0,0 +1,148 @@
+package notifier
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// overflowTestServer holds requests until release is closed and records received messages.
+type overflowTestServer struct {
+	*httptest.Server
+	started  chan struct{}
+	release  chan struct{}
+	mu       sync.Mutex
+	received []string
+}
+
+func newOverflowTestServer() *overflowTestServer {
+	s := &overflowTestServer{
+		started: make(chan struct{}, 10),
+		release: make(chan struct{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		s.started <- struct{}{}
+		<-s.release
+		s.mu.Lock()
+		s.received = append(s.received, string(body))
+		s.mu.Unlock()
+		writer.WriteHeader(http.StatusOK)
+	}))
+	return s
+}
+
+func (s *overflowTestServer) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sort.Strings(s.received)
+	return s.received
+}
+
+func TestNotifier_OverflowPolicy(t *testing.T) {
+	newNotifier := func(url string, policy OverflowPolicy, queueDepth int) (*Client, chan string) {
+		notifier := New(url, &ClientParams{
+			MaxConcurrentWorkers: 1,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   1,
+			MaxQueueDepth:        queueDepth,
+			OverflowPolicy:       policy,
+		})
+		dropped := make(chan string, 10)
+		notifier.OnError(func(message []byte, err error) {
+			assert.True(t, errors.Is(err, &NotifyErr{Type: TypeWorkersLimitExceeded}))
+			dropped <- string(message)
+		})
+		return notifier, dropped
+	}
+
+	t.Run("Reject", func(t *testing.T) {
+		testSrv := newOverflowTestServer()
+		defer testSrv.Close()
+		notifier, dropped := newNotifier(testSrv.URL, OverflowReject, 0)
+
+		n, err := notifier.Notify([]byte("1"), []byte("2"))
+		close(testSrv.release)
+		notifier.Wait()
+
+		assert.Equal(t, 1, n)
+		var notifyErr *NotifyErr
+		require.True(t, errors.As(err, &notifyErr))
+		assert.Equal(t, TypeWorkersLimitExceeded, notifyErr.Type)
+		assert.Equal(t, [][]byte{[]byte("2")}, notifyErr.Remaining)
+		assert.Empty(t, dropped)
+		assert.Equal(t, []string{"1"}, testSrv.messages())
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		testSrv := newOverflowTestServer()
+		defer testSrv.Close()
+		notifier, dropped := newNotifier(testSrv.URL, OverflowBlock, 0)
+
+		result := make(chan int, 1)
+		go func() {
+			n, err := notifier.Notify([]byte("1"), []byte("2"))
+			assert.NoError(t, err)
+			result <- n
+		}()
+		<-testSrv.started
+		select {
+		case <-result:
+			t.Fatal("Notify returned while workers limit is exceeded")
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(testSrv.release)
+
+		assert.Equal(t, 2, <-result)
+		notifier.Wait()
+		assert.Empty(t, dropped)
+		assert.Equal(t, []string{"1", "2"}, testSrv.messages())
+	})
+
+	t.Run("DropOldest", func(t *testing.T) {
+		testSrv := newOverflowTestServer()
+		defer testSrv.Close()
+		notifier, dropped := newNotifier(testSrv.URL, OverflowDropOldest, 1)
+
+		n, err := notifier.Notify([]byte("1"), []byte("2"), []byte("3"))
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+		assert.Equal(t, "2", <-dropped)
+		close(testSrv.release)
+		notifier.Wait()
+
+		assert.Empty(t, dropped)
+		assert.Equal(t, []string{"1", "3"}, testSrv.messages())
+	})
+
+	t.Run("DropNewest", func(t *testing.T) {
+		testSrv := newOverflowTestServer()
+		defer testSrv.Close()
+		notifier, dropped := newNotifier(testSrv.URL, OverflowDropNewest, 1)
+
+		n, err := notifier.Notify([]byte("1"), []byte("2"), []byte("3"))
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+		assert.Equal(t, "3", <-dropped)
+		close(testSrv.release)
+		notifier.Wait()
+
+		assert.Empty(t, dropped)
+		assert.Equal(t, []string{"1", "2"}, testSrv.messages())
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		_, err := NewValidated("http://localhost", &ClientParams{OverflowPolicy: OverflowDropOldest})
+		assert.Error(t, err)
+		_, err = NewValidated("http://localhost", &ClientParams{OverflowPolicy: OverflowPolicy(10)})
+		assert.Error(t, err)
+	})
+}
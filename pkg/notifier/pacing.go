@@ -0,0 +1,55 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// clock provides current time and timers. It's replaced in tests.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is a clock which uses time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// pacer spaces consecutive sends by at least interval.
+// Unlike rate limiter it doesn't allow bursts.
+type pacer struct {
+	mu       sync.Mutex
+	clock    clock
+	interval time.Duration
+	next     time.Time
+}
+
+// newPacer creates pacer with interval between sends.
+func newPacer(c clock, interval time.Duration) *pacer {
+	return &pacer{clock: c, interval: interval}
+}
+
+// wait reserves the next send slot and blocks until it comes or ctx is done.
+func (p *pacer) wait(ctx context.Context) error {
+	p.mu.Lock()
+	now := p.clock.Now()
+	at := p.next
+	if at.Before(now) {
+		at = now
+	}
+	p.next = at.Add(p.interval)
+	p.mu.Unlock()
+
+	if !at.After(now) {
+		return nil
+	}
+	select {
+	case <-p.clock.After(at.Sub(now)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a clock with fixed time which records requested waits and doesn't block.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now.Add(d)
+	return ch
+}
+
+func TestPacer(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	p := newPacer(clock, time.Second)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, p.wait(context.Background()))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, clock.waits)
+
+	clock.now = clock.now.Add(5 * time.Second)
+	require.NoError(t, p.wait(context.Background()))
+	assert.Len(t, clock.waits, 2, "send after idle period shouldn't wait")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p = newPacer(realClock{}, time.Hour)
+	require.NoError(t, p.wait(ctx))
+	assert.Error(t, p.wait(ctx))
+}
+
+func TestNotifier_MinInterval(t *testing.T) {
+	var mu sync.Mutex
+	var received []time.Time
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mu.Lock()
+		received = append(received, time.Now())
+		mu.Unlock()
+	}))
+	defer testSrv.Close()
+
+	const interval = 30 * time.Millisecond
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 5,
+		MaxRequestsPerRate:   5,
+		MinInterval:          interval,
+	})
+	_, err := notifier.Notify(generateTestMessages(5)...)
+	require.NoError(t, err)
+	notifier.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 5)
+	for i := 1; i < len(received); i++ {
+		// Small tolerance for the time between leaving pacer and handling request on the server.
+		assert.GreaterOrEqual(t, int64(received[i].Sub(received[i-1])), int64(interval-5*time.Millisecond))
+	}
+}
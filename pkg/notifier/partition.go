@@ -0,0 +1,27 @@
+package notifier
+
+import (
+	"hash/fnv"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// partition returns partition number of the message in range [0, partitions).
+func partition(message []byte, partitions int) int {
+	h := fnv.New32a()
+	h.Write(message) //nolint: errcheck, gosec
+	return int(h.Sum32() % uint32(partitions))
+}
+
+// partitionURL appends "/p{N}" partition path to rawURL.
+// If rawURL can't be parsed it's returned as is, so the error is reported when request is created.
+func partitionURL(rawURL string, partition int) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/p" + strconv.Itoa(partition)
+	u.RawPath = ""
+	return u.String()
+}
@@ -0,0 +1,51 @@
+package notifier
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionURL(t *testing.T) {
+	assert.Equal(t, "http://localhost/p3", partitionURL("http://localhost", 3))
+	assert.Equal(t, "http://localhost/events/p0?key=value", partitionURL("http://localhost/events/?key=value", 0))
+}
+
+func TestNotifier_Partitions(t *testing.T) {
+	var mu sync.Mutex
+	paths := make(map[string][]string)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		mu.Lock()
+		paths[string(body)] = append(paths[string(body)], request.URL.Path)
+		mu.Unlock()
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 100,
+		MaxRequestRate:       0,
+		Partitions:           4,
+	})
+	messages := generateTestMessages(100)
+	for i := 0; i < 2; i++ {
+		_, err := notifier.Notify(messages...)
+		require.NoError(t, err)
+		notifier.Wait()
+	}
+
+	hit := make(map[string]bool)
+	require.Len(t, paths, len(messages))
+	for msg, msgPaths := range paths {
+		require.Len(t, msgPaths, 2)
+		assert.Equal(t, msgPaths[0], msgPaths[1], "message %q must be always sent to the same partition", msg)
+		hit[msgPaths[0]] = true
+	}
+	assert.Equal(t, map[string]bool{"/p0": true, "/p1": true, "/p2": true, "/p3": true}, hit)
+}
@@ -0,0 +1,100 @@
+package notifier
+
+import (
+	"sync"
+	"time"
+)
+
+// errorPause tracks results of last sends in a sliding window.
+// When error rate in the window exceeds threshold it pauses sending for cooldown period.
+type errorPause struct {
+	gate
+
+	windowMu  sync.Mutex
+	window    []bool
+	next      int
+	size      int
+	failures  int
+	threshold float64
+	cooldown  time.Duration
+
+	// onPause and onResume are guarded by gate mutex, because they can be set while sending.
+	onPause  func()
+	onResume func()
+	// cooldownTimer resumes sending after the cooldown. It's guarded by gate mutex.
+	cooldownTimer *time.Timer
+}
+
+// newErrorPause creates errorPause which uses last windowSize results to calculate error rate.
+func newErrorPause(windowSize int, threshold float64, cooldown time.Duration) *errorPause {
+	return &errorPause{
+		window:    make([]bool, windowSize),
+		threshold: threshold,
+		cooldown:  cooldown,
+		onPause:   func() {},
+		onResume:  func() {},
+	}
+}
+
+// record adds send result to the window and pauses sending if error rate is too high.
+// Error rate is checked only when the window is full. Results are ignored while sending is paused.
+func (p *errorPause) record(failed bool) {
+	if p.isClosed() {
+		return
+	}
+
+	p.windowMu.Lock()
+	if p.size == len(p.window) {
+		if p.window[p.next] {
+			p.failures--
+		}
+	} else {
+		p.size++
+	}
+	p.window[p.next] = failed
+	if failed {
+		p.failures++
+	}
+	p.next = (p.next + 1) % len(p.window)
+
+	if p.size < len(p.window) || float64(p.failures)/float64(p.size) <= p.threshold || !p.close() {
+		p.windowMu.Unlock()
+		return
+	}
+	p.next, p.size, p.failures = 0, 0, 0
+	p.windowMu.Unlock()
+
+	p.handler(&p.onPause)()
+	p.mu.Lock()
+	p.cooldownTimer = time.AfterFunc(p.cooldown, func() {
+		p.open()
+		p.handler(&p.onResume)()
+	})
+	p.mu.Unlock()
+}
+
+// stop stops pending cooldown and opens the gate, so restarted Client is not paused.
+// onResume isn't called, because sending is not resumed after the stop.
+func (p *errorPause) stop() {
+	p.mu.Lock()
+	timer := p.cooldownTimer
+	p.cooldownTimer = nil
+	p.mu.Unlock()
+	if timer != nil && timer.Stop() {
+		p.open()
+	}
+}
+
+// handler returns handler stored in h, which is either onPause or onResume.
+func (p *errorPause) handler(h *func()) func() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return *h
+}
+
+// setHandler replaces handler stored in h, which is either onPause or onResume.
+func (p *errorPause) setHandler(h *func(), handler func()) {
+	p.mu.Lock()
+	*h = handler
+	p.mu.Unlock()
+}
@@ -0,0 +1,143 @@
+package notifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_PauseOnErrors(t *testing.T) {
+	var failing int32 = 1
+	received := make(chan time.Time, 10)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		received <- time.Now()
+		if atomic.LoadInt32(&failing) == 1 {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	cooldown := 200 * time.Millisecond
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   4,
+		PauseErrorRate:       0.5,
+		PauseWindow:          4,
+		PauseCooldown:        cooldown,
+	})
+	paused := make(chan time.Time, 1)
+	resumed := make(chan time.Time, 1)
+	notifier.OnPause(func() { paused <- time.Now() })
+	notifier.OnResume(func() { resumed <- time.Now() })
+
+	_, err := notifier.Notify(generateTestMessages(4)...)
+	require.NoError(t, err)
+	notifier.Wait()
+	for i := 0; i < 4; i++ {
+		<-received
+	}
+
+	var pausedAt time.Time
+	select {
+	case pausedAt = <-paused:
+	case <-time.After(time.Second):
+		t.Fatal("Client must pause sending when error rate is exceeded")
+	}
+
+	atomic.StoreInt32(&failing, 0)
+	_, err = notifier.Notify([]byte("after pause"))
+	require.NoError(t, err)
+	notifier.Wait()
+
+	assert.True(t, (<-received).Sub(pausedAt) >= cooldown, "message must be sent after cooldown")
+	select {
+	case resumedAt := <-resumed:
+		assert.True(t, resumedAt.Sub(pausedAt) >= cooldown)
+	case <-time.After(time.Second):
+		t.Fatal("Client must resume sending after cooldown")
+	}
+}
+
+func TestNotifier_PauseHandlersWhileSending(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   4,
+		PauseErrorRate:       0.5,
+		PauseWindow:          4,
+		PauseCooldown:        time.Millisecond,
+	})
+	paused := make(chan struct{}, 1)
+	_, err := notifier.Notify(generateTestMessages(4)...)
+	require.NoError(t, err)
+	// Handlers are replaced while workers record results, which is caught by the race detector if unguarded.
+	notifier.OnPause(func() {
+		select {
+		case paused <- struct{}{}:
+		default:
+		}
+	})
+	notifier.OnResume(func() {})
+	notifier.Wait()
+
+	_, err = notifier.Notify(generateTestMessages(4)...)
+	require.NoError(t, err)
+	notifier.Wait()
+	select {
+	case <-paused:
+	case <-time.After(time.Second):
+		t.Fatal("handler set while sending must be called")
+	}
+}
+
+func TestNotifier_PauseStop(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer testSrv.Close()
+
+	cooldown := 100 * time.Millisecond
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   4,
+		PauseErrorRate:       0.5,
+		PauseWindow:          4,
+		PauseCooldown:        cooldown,
+	})
+	paused := make(chan struct{}, 1)
+	resumed := make(chan struct{}, 1)
+	notifier.OnPause(func() { paused <- struct{}{} })
+	notifier.OnResume(func() { resumed <- struct{}{} })
+
+	_, err := notifier.Notify(generateTestMessages(4)...)
+	require.NoError(t, err)
+	notifier.Wait()
+	select {
+	case <-paused:
+	case <-time.After(time.Second):
+		t.Fatal("Client must pause sending when error rate is exceeded")
+	}
+
+	notifier.Stop()
+	select {
+	case <-resumed:
+		t.Fatal("cooldown must be stopped with Client")
+	case <-time.After(2 * cooldown):
+	}
+	require.NoError(t, notifier.Restart())
+	assert.False(t, notifier.pause.isClosed(), "restarted Client must not be paused")
+}
@@ -0,0 +1,55 @@
+package notifier
+
+import "encoding/json"
+
+// PayloadFormat defines how encoded message is wrapped into request body.
+type PayloadFormat int
+
+const (
+	// FormatRaw sends encoded message as request body.
+	FormatRaw PayloadFormat = iota
+	// FormatJSON wraps encoded message into JSON object like {"message":"..."}.
+	// Name of the field is set by ClientParams.PayloadField.
+	FormatJSON
+)
+
+// defaultPayloadField is used by FormatJSON if ClientParams.PayloadField is empty.
+const defaultPayloadField = "message"
+
+// contentTypeJSON used as Content-Type of messages wrapped by FormatJSON.
+const contentTypeJSON = "application/json"
+
+// Encoder builds request body of the message, see ClientParams.Encoder.
+// It's called concurrently from different workers.
+type Encoder interface {
+	// Encode returns request body and its Content-Type. Content-Type is not set if it is empty.
+	Encode(message []byte) (body []byte, contentType string, err error)
+}
+
+// builtinEncoder applies ClientParams.Encoding and ClientParams.PayloadFormat.
+// It sends messages as is by default.
+type builtinEncoder struct {
+	encoding Encoding
+	format   PayloadFormat
+	field    string
+}
+
+// newBuiltinEncoder creates Encoder configured by params.
+func newBuiltinEncoder(params *ClientParams) builtinEncoder {
+	e := builtinEncoder{encoding: params.Encoding, format: params.PayloadFormat, field: params.PayloadField}
+	if e.field == "" {
+		e.field = defaultPayloadField
+	}
+	return e
+}
+
+// Encode implements Encoder interface.
+// FormatJSON replaces invalid UTF-8 sequences by U+FFFD, so use Encoding for binary messages.
+func (e builtinEncoder) Encode(message []byte) ([]byte, string, error) {
+	body := e.encoding.encode(message)
+	if e.format != FormatJSON {
+		return body, e.encoding.contentType(), nil
+	}
+	body, err := json.Marshal(map[string]string{e.field: string(body)})
+	return body, contentTypeJSON, err
+}
@@ -0,0 +1,143 @@
+package notifier
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_PayloadFormat(t *testing.T) {
+	type request struct {
+		contentType string
+		body        []byte
+	}
+	requests := make(chan request, 1)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		requests <- request{contentType: req.Header.Get("Content-Type"), body: body}
+	}))
+	defer testSrv.Close()
+
+	message := []byte("line 1\n\"quoted\" \\ <tag> \x01")
+	tests := []struct {
+		name     string
+		params   ClientParams
+		field    string
+		expected string
+	}{
+		{
+			name:     "JSON",
+			params:   ClientParams{PayloadFormat: FormatJSON},
+			field:    "message",
+			expected: string(message),
+		},
+		{
+			name:     "JSON with custom field",
+			params:   ClientParams{PayloadFormat: FormatJSON, PayloadField: "text"},
+			field:    "text",
+			expected: string(message),
+		},
+		{
+			name:     "JSON with encoding",
+			params:   ClientParams{PayloadFormat: FormatJSON, Encoding: EncodingHex},
+			field:    "message",
+			expected: "6c696e6520310a2271756f74656422205c203c7461673e2001",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			params := tc.params
+			params.MaxConcurrentWorkers = 1
+			params.MaxRequestRate = time.Millisecond
+			params.MaxRequestsPerRate = 1
+			notifier := New(testSrv.URL, &params)
+			_, err := notifier.Notify(message)
+			require.NoError(t, err)
+			notifier.Wait()
+
+			r := <-requests
+			assert.Equal(t, "application/json", r.contentType)
+			var payload map[string]string
+			require.NoError(t, json.Unmarshal(r.body, &payload), "body must be valid JSON: %s", r.body)
+			assert.Equal(t, map[string]string{tc.field: tc.expected}, payload)
+		})
+	}
+
+	t.Run("Raw", func(t *testing.T) {
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 1,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   1,
+		})
+		_, err := notifier.Notify(message)
+		require.NoError(t, err)
+		notifier.Wait()
+
+		r := <-requests
+		assert.Empty(t, r.contentType)
+		assert.Equal(t, message, r.body)
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		_, err := NewValidated(testSrv.URL, &ClientParams{PayloadFormat: FormatJSON + 1})
+		assert.Error(t, err)
+	})
+}
+
+// base64Encoder is a fake Encoder sending messages encoded using base64.
+type base64Encoder struct{}
+
+func (base64Encoder) Encode(message []byte) ([]byte, string, error) {
+	if len(message) == 0 {
+		return nil, "", errors.New("empty message")
+	}
+	return []byte(base64.StdEncoding.EncodeToString(message)), "application/x-base64", nil
+}
+
+func TestNotifier_Encoder(t *testing.T) {
+	type request struct {
+		contentType, body string
+	}
+	requests := make(chan request, 1)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		requests <- request{contentType: req.Header.Get("Content-Type"), body: string(body)}
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		PayloadFormat:        FormatJSON,
+		Encoder:              base64Encoder{},
+	})
+	var failed []error
+	notifier.OnError(func(message []byte, err error) {
+		failed = append(failed, err)
+	})
+	_, err := notifier.Notify([]byte("hello"))
+	require.NoError(t, err)
+	notifier.Wait()
+
+	r := <-requests
+	assert.Equal(t, "application/x-base64", r.contentType)
+	assert.Equal(t, "aGVsbG8=", r.body)
+
+	_, err = notifier.Notify([]byte{})
+	require.NoError(t, err)
+	notifier.Wait()
+	require.Len(t, failed, 1)
+	var nErr *NotifyErr
+	require.True(t, errors.As(failed[0], &nErr))
+	assert.Equal(t, TypeSendError, nErr.Type)
+	assert.EqualError(t, nErr.Err, "empty message")
+}
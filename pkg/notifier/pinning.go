@@ -0,0 +1,25 @@
+package notifier
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+)
+
+// verifyPinnedCert returns tls.Config.VerifyPeerCertificate function
+// which checks that SHA-256 hash of the leaf certificate matches one of pins.
+func verifyPinnedCert(pins [][]byte) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("no server certificate to verify pin")
+		}
+		sum := sha256.Sum256(rawCerts[0])
+		for _, pin := range pins {
+			if bytes.Equal(pin, sum[:]) {
+				return nil
+			}
+		}
+		return errors.New("server certificate doesn't match any pinned certificate")
+	}
+}
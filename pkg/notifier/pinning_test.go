@@ -0,0 +1,55 @@
+package notifier
+
+import (
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_PinnedCertSHA256(t *testing.T) {
+	testSrv := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+	pin := sha256.Sum256(testSrv.Certificate().Raw)
+
+	send := func(pins [][]byte) error {
+		params := &ClientParams{
+			MaxConcurrentWorkers: 1,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   1,
+			PinnedCertSHA256:     pins,
+		}
+		transport := testSrv.Client().Transport.(*http.Transport).Clone()
+		configureTransport(transport, params)
+
+		var sendErr error
+		notifier := create(testSrv.URL, params, transport)
+		notifier.OnError(func(message []byte, err error) {
+			sendErr = err
+		})
+		_, err := notifier.Notify([]byte("test message"))
+		require.NoError(t, err)
+		notifier.Wait()
+		return sendErr
+	}
+
+	t.Run("Matching pin", func(t *testing.T) {
+		assert.NoError(t, send([][]byte{[]byte("other pin"), pin[:]}))
+	})
+
+	t.Run("Mismatched pin", func(t *testing.T) {
+		err := send([][]byte{make([]byte, sha256.Size)})
+
+		var nErr *NotifyErr
+		require.True(t, errors.As(err, &nErr))
+		assert.Equal(t, msgSendErrorClient, nErr.Message)
+		assert.Contains(t, nErr.Error(), "pinned certificate")
+	})
+}
@@ -0,0 +1,262 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+)
+
+// overflowQueue keeps tasks which have not got a worker until a worker is free.
+type overflowQueue struct {
+	mu     sync.Mutex
+	closed bool
+	// size is a number of queued tasks including tasks taken by drainQueue which are still waiting
+	// for the rate or a worker, so the queue never holds more than cap(tasks) tasks.
+	size  int
+	tasks chan task
+}
+
+// newOverflowQueue creates overflowQueue which holds up to depth tasks.
+func newOverflowQueue(depth int) *overflowQueue {
+	return &overflowQueue{tasks: make(chan task, depth)}
+}
+
+// enqueue tries to add task to the queue and reports if it has been added.
+// hold is called for every added task before it becomes visible to the drainer.
+func (q *overflowQueue) enqueue(t task, hold func()) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || q.size == cap(q.tasks) {
+		return false
+	}
+	hold()
+	q.size++
+	q.tasks <- t
+	return true
+}
+
+// evictAndEnqueue adds task to the queue evicting the oldest task if the queue is full.
+// It returns evicted task if any and reports if task has been added.
+// Tasks already taken by drainQueue can't be evicted, so task isn't added if there are no others.
+func (q *overflowQueue) evictAndEnqueue(t task, hold func()) (*task, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, false
+	}
+	var evicted *task
+	if q.size == cap(q.tasks) {
+		select {
+		case oldest := <-q.tasks:
+			evicted = &oldest
+		default:
+			return nil, false
+		}
+	} else {
+		q.size++
+	}
+	hold()
+	q.tasks <- t
+	return evicted, true
+}
+
+// release frees the place of the task which has left the queue.
+func (q *overflowQueue) release() {
+	q.mu.Lock()
+	q.size--
+	q.mu.Unlock()
+}
+
+// reopen allows adding tasks to the closed queue again.
+func (q *overflowQueue) reopen() {
+	q.mu.Lock()
+	q.closed = false
+	q.mu.Unlock()
+}
+
+// close prevents adding new tasks to the queue.
+func (q *overflowQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+}
+
+// drainQueue starts queued tasks as soon as workers become free.
+// Every task waits for the rate before acquiring a worker, so workers are not held by rate limiter.
+// Tasks are passed to queueWaiters, so tasks of a throttled key don't delay tasks of other keys.
+// When ctx is done all queued tasks are reported as canceled.
+func (c *Client) drainQueue(ctx context.Context) {
+	waiters := newQueueWaiters()
+	defer waiters.wait()
+	start := func(t task) {
+		c.startQueued(ctx, t)
+	}
+	for {
+		select {
+		case t := <-c.queue.tasks:
+			var key waiterKey
+			if !t.rateWaited && t.reader == nil {
+				key = waiterKey{rate: true, rateKey: c.rateKey(t.message)}
+			}
+			waiters.add(key, t, start)
+		case <-ctx.Done():
+			c.queue.close()
+			for {
+				select {
+				case t := <-c.queue.tasks:
+					c.cancelQueued(ctx, t)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// startQueued starts queued task. Task is canceled if ctx is done.
+func (c *Client) startQueued(ctx context.Context, t task) {
+	if ctx.Err() != nil {
+		c.cancelQueued(ctx, t)
+		return
+	}
+	if err := c.waitQueuedRate(&t); err != nil {
+		c.cancelTask(t, err)
+	} else if err := c.acquireQueuedWorker(ctx, t); err != nil {
+		c.cancelTask(t, err)
+	} else {
+		c.startWorker(t)
+	}
+	c.queue.release()
+	c.workers.Done()
+}
+
+// waiterKey identifies queued tasks which wait for the same rate limiter.
+// Tasks which don't wait for the rate share zero key.
+type waiterKey struct {
+	rate    bool
+	rateKey string
+}
+
+// queueWaiters runs a goroutine for every waiterKey which starts its tasks in the order they have been queued.
+// Goroutine exits as soon as it has no tasks, so keys which are not used don't hold goroutines.
+type queueWaiters struct {
+	mu    sync.Mutex
+	wg    sync.WaitGroup
+	tasks map[waiterKey][]task
+}
+
+// newQueueWaiters creates empty queueWaiters.
+func newQueueWaiters() *queueWaiters {
+	return &queueWaiters{tasks: make(map[waiterKey][]task)}
+}
+
+// add passes task to the goroutine of key starting the goroutine if needed. start is called for every task.
+func (w *queueWaiters) add(key waiterKey, t task, start func(task)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	tasks, ok := w.tasks[key]
+	w.tasks[key] = append(tasks, t)
+	if !ok {
+		w.wg.Add(1)
+		go w.run(key, start)
+	}
+}
+
+// run starts tasks of key until there are no pending ones.
+func (w *queueWaiters) run(key waiterKey, start func(task)) {
+	defer w.wg.Done()
+	for {
+		w.mu.Lock()
+		tasks := w.tasks[key]
+		if len(tasks) == 0 {
+			delete(w.tasks, key)
+			w.mu.Unlock()
+			return
+		}
+		t := tasks[0]
+		w.tasks[key] = tasks[1:]
+		w.mu.Unlock()
+		start(t)
+	}
+}
+
+// wait blocks until all added tasks are started.
+func (w *queueWaiters) wait() {
+	w.wg.Wait()
+}
+
+// cancelQueued reports queued task as canceled by done ctx.
+func (c *Client) cancelQueued(ctx context.Context, t task) {
+	c.cancelTask(t, &NotifyErr{
+		Type:    TypeContextCanceled,
+		Message: "Client context canceled",
+		Err:     ctx.Err(),
+	})
+	c.queue.release()
+	c.workers.Done()
+}
+
+// waitQueuedRate waits until the first request of queued task is allowed by rate limiter.
+func (c *Client) waitQueuedRate(t *task) *NotifyErr {
+	if t.rateWaited || t.reader != nil {
+		return nil
+	}
+	ctx := c.taskContext(*t)
+	if err := c.waitRate(ctx, t.message); err != nil {
+		if ctx.Err() != nil {
+			return &NotifyErr{
+				Type:    TypeContextCanceled,
+				Message: "Client context canceled",
+				Err:     ctx.Err(),
+			}
+		}
+		return &NotifyErr{
+			Type:    TypeSendError,
+			Message: msgSendErrorRateLimiter,
+			Err:     err,
+		}
+	}
+	t.rateWaited = true
+	return nil
+}
+
+// acquireQueuedWorker waits for a free worker for queued task.
+func (c *Client) acquireQueuedWorker(clientCtx context.Context, t task) *NotifyErr {
+	ctx := c.taskContext(t)
+	select {
+	case c.workersLimiter <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return &NotifyErr{
+			Type:    TypeContextCanceled,
+			Message: "Client context canceled",
+			Err:     ctx.Err(),
+		}
+	case <-clientCtx.Done():
+		return &NotifyErr{
+			Type:    TypeContextCanceled,
+			Message: "Client context canceled",
+			Err:     clientCtx.Err(),
+		}
+	}
+}
+
+// startDrainQueue starts drainQueue for the current Client context.
+// Returned channel is closed when drainQueue returns.
+func (c *Client) startDrainQueue() <-chan struct{} {
+	ctx := c.context()
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		c.drainQueue(ctx)
+	}()
+	return drained
+}
+
+// cancelTask reports that task will not be sent.
+func (c *Client) cancelTask(t task, err error) {
+	c.metrics.recordRejected(t.count())
+	c.notifyError(t.message, err)
+	if t.done != nil {
+		t.done(err)
+	}
+}
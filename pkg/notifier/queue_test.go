@@ -0,0 +1,187 @@
+package notifier
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_MaxQueueDepth(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	release := make(chan struct{})
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		<-release
+		mu.Lock()
+		received = append(received, string(body))
+		mu.Unlock()
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		MaxQueueDepth:        2,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+
+	n, err := notifier.Notify([]byte("1"), []byte("2"), []byte("3"), []byte("4"))
+	assert.Equal(t, 1, n)
+	var notifyErr *NotifyErr
+	require.True(t, errors.As(err, &notifyErr))
+	assert.Equal(t, TypeWorkersLimitExceeded, notifyErr.Type)
+	assert.Equal(t, [][]byte{[]byte("2"), []byte("3"), []byte("4")}, notifyErr.Remaining)
+	assert.Equal(t, 2, notifyErr.Queued)
+
+	close(release)
+	notifier.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(received)
+	assert.Equal(t, []string{"1", "2", "3"}, received)
+}
+
+func TestNotifier_MaxQueueDepthStop(t *testing.T) {
+	release := make(chan struct{})
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = ioutil.ReadAll(request.Body)
+		select {
+		case <-release:
+		case <-request.Context().Done():
+		}
+	}))
+	defer testSrv.Close()
+	defer close(release)
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		MaxQueueDepth:        2,
+	})
+	canceled := make(chan []byte, 3)
+	notifier.OnError(func(message []byte, err error) {
+		canceled <- message
+	})
+
+	_, err := notifier.Notify([]byte("1"), []byte("2"), []byte("3"))
+	require.Error(t, err)
+	notifier.Stop()
+	notifier.Wait()
+
+	assert.Len(t, canceled, 3)
+}
+
+func TestNotifier_QueueWaitsRate(t *testing.T) {
+	const interval = 200 * time.Millisecond
+	var mu sync.Mutex
+	received := make(map[string]time.Time)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		mu.Lock()
+		received[string(body)] = time.Now()
+		mu.Unlock()
+		time.Sleep(interval / 2)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       interval,
+		MaxRequestsPerRate:   1,
+		MaxQueueDepth:        2,
+		RateKeyFunc: func(message []byte) string {
+			return string(message[:1])
+		},
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+
+	start := time.Now()
+	// "a:2" waits for the rate in the queue, so it doesn't hold a worker needed by "b:1".
+	n, err := notifier.Notify([]byte("a:1"), []byte("a:2"), []byte("b:1"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	notifier.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 3)
+	assert.Less(t, int64(received["b:1"].Sub(start)), int64(interval/4))
+	assert.GreaterOrEqual(t, int64(received["a:2"].Sub(received["a:1"])), int64(interval-10*time.Millisecond))
+}
+
+func TestNotifier_QueueRatePerKey(t *testing.T) {
+	const interval = 200 * time.Millisecond
+	var mu sync.Mutex
+	received := make(map[string]time.Time)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		mu.Lock()
+		received[string(body)] = time.Now()
+		mu.Unlock()
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestRate:       interval,
+		MaxRequestsPerRate:   1,
+		MaxQueueDepth:        3,
+		RateKeyFunc: func(message []byte) string {
+			return string(message[:1])
+		},
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+
+	start := time.Now()
+	// "a:2" and "a:3" are queued before "b:2", but waiting for the rate of "a" doesn't delay "b".
+	n, err := notifier.Notify([]byte("a:1"), []byte("b:1"), []byte("a:2"), []byte("a:3"), []byte("b:2"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	notifier.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 5)
+	assert.GreaterOrEqual(t, int64(received["a:3"].Sub(start)), int64(2*interval-10*time.Millisecond))
+	assert.GreaterOrEqual(t, int64(received["b:2"].Sub(start)), int64(interval-10*time.Millisecond))
+	assert.Less(t, int64(received["b:2"].Sub(start)), int64(interval+interval/2))
+}
+
+func TestQueueWaiters_StopIdle(t *testing.T) {
+	waiters := newQueueWaiters()
+	var mu sync.Mutex
+	var started []string
+	start := func(t task) {
+		mu.Lock()
+		started = append(started, string(t.message))
+		mu.Unlock()
+	}
+	for i := 0; i < 100; i++ {
+		waiters.add(waiterKey{rate: true, rateKey: strconv.Itoa(i)}, task{message: []byte(strconv.Itoa(i))}, start)
+	}
+	waiters.wait()
+
+	// Every goroutine has exited after starting its tasks.
+	assert.Empty(t, waiters.tasks)
+	assert.Len(t, started, 100)
+}
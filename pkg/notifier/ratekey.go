@@ -0,0 +1,97 @@
+package notifier
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// keyedLimiters keeps a separate rate limiter for every key.
+// Limiter which hasn't been used for the time needed to refill its burst is removed,
+// because a new limiter of the key behaves the same way, so number of kept limiters is bounded by active keys.
+type keyedLimiters struct {
+	mu         sync.Mutex
+	limiters   map[string]*keyedLimiter
+	newLimiter func() *rate.Limiter
+	// idle is a time after which unused limiter is full again.
+	idle  time.Duration
+	swept time.Time
+}
+
+// keyedLimiter is a rate limiter of the key.
+type keyedLimiter struct {
+	*rate.Limiter
+	// users is a number of callers which have acquired the limiter and not released it yet.
+	users    int
+	lastUsed time.Time
+}
+
+// newKeyedLimiters creates keyedLimiters which creates limiters for new keys using newLimiter.
+func newKeyedLimiters(newLimiter func() *rate.Limiter) *keyedLimiters {
+	var idle time.Duration
+	if l := newLimiter(); l.Limit() != rate.Inf && l.Limit() > 0 {
+		idle = time.Duration(float64(l.Burst()) / float64(l.Limit()) * float64(time.Second))
+	}
+	return &keyedLimiters{
+		limiters:   make(map[string]*keyedLimiter),
+		newLimiter: newLimiter,
+		idle:       idle,
+		swept:      time.Now(),
+	}
+}
+
+// acquire returns limiter of the key creating it if needed. The limiter is kept until release is called.
+func (k *keyedLimiters) acquire(key string) *rate.Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if now := time.Now(); now.Sub(k.swept) >= k.idle {
+		k.sweep(now)
+	}
+	l, ok := k.limiters[key]
+	if !ok {
+		l = &keyedLimiter{Limiter: k.newLimiter()}
+		k.limiters[key] = l
+	}
+	l.users++
+	return l.Limiter
+}
+
+// release marks limiter of the key as used right now.
+func (k *keyedLimiters) release(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if l, ok := k.limiters[key]; ok {
+		l.users--
+		l.lastUsed = time.Now()
+	}
+}
+
+// sweep removes limiters which are not used and have been idle long enough to be full again.
+func (k *keyedLimiters) sweep(now time.Time) {
+	for key, l := range k.limiters {
+		if l.users == 0 && now.Sub(l.lastUsed) >= k.idle {
+			delete(k.limiters, key)
+		}
+	}
+	k.swept = now
+}
+
+// limiter returns rate limiter for the message and a function which must be called when the limiter is used.
+// It's a limiter of the message key if RateKeyFunc is set and Client limiter otherwise.
+// Bodies sent by NotifyReaders always use Client limiter.
+func (c *Client) limiter(message []byte) (*rate.Limiter, func()) {
+	if c.rateKeyFunc == nil || message == nil {
+		return c.requestsLimiter, func() {}
+	}
+	key := c.rateKeyFunc(message)
+	return c.keyedLimiters.acquire(key), func() { c.keyedLimiters.release(key) }
+}
+
+// rateKey returns key of the rate limiter of the message. It's empty if Client limiter is used.
+func (c *Client) rateKey(message []byte) string {
+	if c.rateKeyFunc == nil || message == nil {
+		return ""
+	}
+	return c.rateKeyFunc(message)
+}
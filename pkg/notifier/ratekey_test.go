@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestNotifier_RateKeyFunc(t *testing.T) {
+	const interval = 100 * time.Millisecond
+	var mu sync.Mutex
+	received := make(map[string][]time.Time)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		key := strings.SplitN(string(body), ":", 2)[0]
+		mu.Lock()
+		received[key] = append(received[key], time.Now())
+		mu.Unlock()
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 6,
+		MaxRequestRate:       interval,
+		MaxRequestsPerRate:   1,
+		RateKeyFunc: func(message []byte) string {
+			return strings.SplitN(string(message), ":", 2)[0]
+		},
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+
+	start := time.Now()
+	_, err := notifier.Notify(
+		[]byte("a:1"), []byte("b:1"),
+		[]byte("a:2"), []byte("b:2"),
+		[]byte("a:3"), []byte("b:3"),
+	)
+	require.NoError(t, err)
+	notifier.Wait()
+	elapsed := time.Since(start)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, key := range []string{"a", "b"} {
+		times := received[key]
+		require.Len(t, times, 3, key)
+		// Requests of the same key are throttled by the key limiter.
+		assert.GreaterOrEqual(t, int64(times[2].Sub(times[0])), int64(2*interval-10*time.Millisecond), key)
+	}
+	// Keys don't share the budget, otherwise 6 requests would take at least 5 intervals.
+	assert.Less(t, int64(elapsed), int64(4*interval))
+}
+
+func TestKeyedLimiters_EvictIdle(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	limiters := newKeyedLimiters(func() *rate.Limiter {
+		return rate.NewLimiter(rate.Every(interval), 1)
+	})
+
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		assert.True(t, limiters.acquire(key).Allow())
+		limiters.release(key)
+	}
+	held := limiters.acquire("held")
+	assert.Len(t, limiters.limiters, 101)
+
+	time.Sleep(2 * interval)
+	// Limiters which are full again are removed, limiter in use is kept.
+	assert.True(t, limiters.acquire("new").Allow())
+	assert.Len(t, limiters.limiters, 2)
+	assert.Same(t, held, limiters.acquire("held"))
+}
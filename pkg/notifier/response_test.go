@@ -0,0 +1,315 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_ResponseValidator(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+		_, _ = writer.Write([]byte(`{"ok":false}`))
+	}))
+	defer testSrv.Close()
+
+	errValidation := errors.New("server rejected message")
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		ResponseValidator: func(statusCode int, body []byte) error {
+			var ack struct {
+				OK bool `json:"ok"`
+			}
+			if err := json.Unmarshal(body, &ack); err != nil {
+				return err
+			}
+			if !ack.OK {
+				return errValidation
+			}
+			return nil
+		},
+	})
+	errs := make(chan error, 1)
+	notifier.OnError(func(message []byte, err error) {
+		errs <- err
+	})
+	_, err := notifier.Notify([]byte("test message"))
+	require.NoError(t, err)
+	notifier.Wait()
+
+	var nErr *NotifyErr
+	require.True(t, errors.As(<-errs, &nErr))
+	assert.Equal(t, TypeSendError, nErr.Type)
+	assert.Equal(t, msgSendErrorResponse, nErr.Message)
+	assert.Equal(t, http.StatusOK, nErr.StatusCode)
+	assert.True(t, errors.Is(nErr, errValidation))
+}
+
+func TestNotifier_OnCreated(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		if string(body) == "create" {
+			writer.Header().Set("Location", "/resources/1")
+			writer.WriteHeader(http.StatusCreated)
+			return
+		}
+		writer.Header().Set("Location", "/ignored")
+		writer.WriteHeader(http.StatusAccepted)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+	})
+	created := make(chan string, 2)
+	notifier.OnCreated(func(message []byte, location string) {
+		assert.Equal(t, "create", string(message))
+		created <- location
+	})
+	_, err := notifier.Notify([]byte("create"), []byte("accept"))
+	require.NoError(t, err)
+	notifier.Wait()
+
+	require.Len(t, created, 1)
+	assert.Equal(t, "/resources/1", <-created)
+}
+
+func TestNotifier_ResponseBodyDrained(t *testing.T) {
+	const workers = 4
+	testSrv := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(strings.Repeat("response body ", 1024)))
+	}))
+	var conns int32
+	testSrv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	testSrv.Start()
+	defer testSrv.Close()
+
+	transport := getTestTransport()
+	transport.MaxIdleConnsPerHost = workers
+	notifier := create(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: workers,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   workers,
+		BlockWhenFull:        true,
+	}, transport)
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	_, err := notifier.Notify(generateTestMessages(200)...)
+	require.NoError(t, err)
+	notifier.Wait()
+
+	assert.LessOrEqual(t, int(atomic.LoadInt32(&conns)), workers)
+}
+
+func TestNotifier_SuccessStatusCodes(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		switch string(body) {
+		case "internal error":
+			writer.WriteHeader(http.StatusInternalServerError)
+		case "not found":
+			writer.WriteHeader(http.StatusNotFound)
+		case "not modified":
+			writer.WriteHeader(http.StatusNotModified)
+		default:
+			writer.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer testSrv.Close()
+
+	notifyStatuses := func(t *testing.T, params *ClientParams) map[string]int {
+		notifier := New(testSrv.URL, params)
+		failed := make(chan *NotifyErr, 4)
+		notifier.OnError(func(message []byte, err error) {
+			var nErr *NotifyErr
+			if !assert.True(t, errors.As(err, &nErr)) {
+				return
+			}
+			assert.Equal(t, TypeSendError, nErr.Type)
+			assert.Equal(t, msgSendErrorStatus, nErr.Message)
+			failed <- nErr
+		})
+		_, err := notifier.Notify([]byte("internal error"), []byte("not found"), []byte("not modified"), []byte("no content"))
+		require.NoError(t, err)
+		notifier.Wait()
+		close(failed)
+
+		statuses := make(map[string]int)
+		for nErr := range failed {
+			statuses[http.StatusText(nErr.StatusCode)] = nErr.StatusCode
+		}
+		return statuses
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		statuses := notifyStatuses(t, &ClientParams{
+			MaxConcurrentWorkers: 4,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   4,
+		})
+		assert.Equal(t, map[string]int{
+			"Internal Server Error": http.StatusInternalServerError,
+			"Not Found":             http.StatusNotFound,
+			"Not Modified":          http.StatusNotModified,
+		}, statuses)
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		statuses := notifyStatuses(t, &ClientParams{
+			MaxConcurrentWorkers: 4,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   4,
+			SuccessStatusCodes:   []int{http.StatusNoContent, http.StatusNotModified, http.StatusNotFound},
+		})
+		assert.Equal(t, map[string]int{
+			"Internal Server Error": http.StatusInternalServerError,
+		}, statuses)
+	})
+}
+
+func TestNotifier_OnSuccess(t *testing.T) {
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		if string(body) == "fail" {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		writer.Header().Set("X-Message", string(body))
+		_, _ = writer.Write([]byte("ok"))
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   4,
+	})
+	delivered := make(chan string, 4)
+	notifier.OnSuccess(func(message []byte, resp *http.Response) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, string(message), resp.Header.Get("X-Message"))
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Empty(t, body)
+		delivered <- string(message)
+	})
+	_, err := notifier.Notify([]byte("1"), []byte("fail"), []byte("2"), []byte("3"))
+	require.NoError(t, err)
+	notifier.Wait()
+	close(delivered)
+
+	var messages []string
+	for message := range delivered {
+		messages = append(messages, message)
+	}
+	assert.ElementsMatch(t, []string{"1", "2", "3"}, messages)
+}
+
+func TestNotifier_ResponseDecoders(t *testing.T) {
+	const protobufType = "application/x-protobuf"
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		if request.Header.Get("Accept") == protobufType {
+			writer.Header().Set("Content-Type", protobufType)
+			_, _ = writer.Write(append([]byte{0x0a, byte(len(body))}, body...))
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(writer).Encode(map[string]string{"id": string(body)})
+	}))
+	defer testSrv.Close()
+
+	decoders := map[string]ResponseDecoder{
+		"application/json": func(body []byte) (interface{}, error) {
+			var ack struct {
+				ID string `json:"id"`
+			}
+			err := json.Unmarshal(body, &ack)
+			return "json:" + ack.ID, err
+		},
+		protobufType: func(body []byte) (interface{}, error) {
+			// Message with a single string field 1.
+			if len(body) < 2 || body[0] != 0x0a || int(body[1]) != len(body)-2 {
+				return nil, errors.New("malformed message")
+			}
+			return "protobuf:" + string(body[2:]), nil
+		},
+	}
+
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{"application/json", "json:message"},
+		{protobufType, "protobuf:message"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			notifier := New(testSrv.URL, &ClientParams{
+				MaxConcurrentWorkers: 1,
+				MaxRequestRate:       time.Millisecond,
+				MaxRequestsPerRate:   1,
+				Accept:               tt.accept,
+				ResponseDecoders:     decoders,
+			})
+			notifier.OnError(func(message []byte, err error) {
+				t.Errorf("unexpected error: %v", err)
+			})
+			acks := make(chan interface{}, 1)
+			notifier.OnAck(func(message []byte, ack interface{}) {
+				assert.Equal(t, "message", string(message))
+				acks <- ack
+			})
+			_, err := notifier.Notify([]byte("message"))
+			require.NoError(t, err)
+			notifier.Wait()
+			require.Len(t, acks, 1)
+			assert.Equal(t, tt.expected, <-acks)
+		})
+	}
+
+	t.Run("Decoding error", func(t *testing.T) {
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 1,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   1,
+			Accept:               protobufType,
+			ResponseDecoders: map[string]ResponseDecoder{
+				protobufType: func(body []byte) (interface{}, error) {
+					return nil, errors.New("unsupported version")
+				},
+			},
+		})
+		errs := make(chan error, 1)
+		notifier.OnError(func(message []byte, err error) {
+			errs <- err
+		})
+		_, err := notifier.Notify([]byte("message"))
+		require.NoError(t, err)
+		notifier.Wait()
+
+		var nErr *NotifyErr
+		require.True(t, errors.As(<-errs, &nErr))
+		assert.Equal(t, TypeSendError, nErr.Type)
+		assert.Equal(t, msgSendErrorResponse, nErr.Message)
+	})
+}
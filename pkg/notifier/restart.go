@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// Restart makes Client stopped by Stop or StopGrace accept messages again.
+// It returns an error if Client hasn't been stopped or its workers are still running, so call Wait first.
+// Restart must not be called concurrently with other methods of the Client.
+// Heartbeat has to be started again after Restart. Client closed by Close can't be restarted,
+// because its resources have been released.
+func (c *Client) Restart() error {
+	if c.context().Err() == nil {
+		return errors.New("client has not been stopped")
+	}
+	if c.workers.busy() {
+		return errors.New("client has running workers, call Wait before Restart")
+	}
+	if c.queueDrained != nil {
+		<-c.queueDrained
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.ctxMu.Lock()
+	c.ctx, c.cancel = ctx, cancel
+	c.ctxMu.Unlock()
+	atomic.StoreInt32(&c.stopping, 0)
+	atomic.StoreInt32(&c.draining, 0)
+	if c.queue != nil {
+		c.queue.reopen()
+		c.queueDrained = c.startDrainQueue()
+	}
+	if c.offline != nil && c.offline.isClosed() {
+		// Probing of the stopped Client has ended, so the server is probed again until it's back online.
+		go c.offline.probeUntilOnline(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_Restart(t *testing.T) {
+	var received int32
+	started := make(chan struct{}, 1)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		if string(body) == "slow" {
+			started <- struct{}{}
+			<-request.Context().Done()
+			return
+		}
+		atomic.AddInt32(&received, 1)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		MaxQueueDepth:        2,
+		BlockWhenFull:        true,
+	})
+	assert.Error(t, notifier.Restart(), "running client must not be restarted")
+
+	release := make(chan struct{})
+	notifier.OnError(func(message []byte, err error) {
+		<-release
+	})
+	_, err := notifier.Notify([]byte("slow"))
+	require.NoError(t, err)
+	<-started
+	notifier.Stop()
+	assert.Error(t, notifier.Restart(), "client with running workers must not be restarted")
+	close(release)
+	notifier.Wait()
+
+	_, err = notifier.Notify([]byte("stopped"))
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	require.NoError(t, notifier.Restart())
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	n, err := notifier.Notify(generateTestMessages(3)...)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	notifier.Wait()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&received))
+
+	// Client can be stopped and restarted again.
+	notifier.Stop()
+	notifier.Wait()
+	require.NoError(t, notifier.Restart())
+	_, err = notifier.Notify([]byte("again"))
+	require.NoError(t, err)
+	notifier.Wait()
+	assert.Equal(t, int32(4), atomic.LoadInt32(&received))
+}
+
+func TestNotifier_RestartOffline(t *testing.T) {
+	// Reserve an address and release it, so the server is unreachable until it's started on the address.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	received := make(chan string, 1)
+	testSrv := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method == http.MethodHead {
+			return
+		}
+		body, _ := ioutil.ReadAll(request.Body)
+		received <- string(body)
+	}))
+
+	notifier := New("http://"+addr, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		OfflineAfterFailures: 1,
+		OfflineProbeInterval: 20 * time.Millisecond,
+	})
+	_, err = notifier.Notify([]byte("before"))
+	require.NoError(t, err)
+	require.Eventually(t, notifier.offline.isClosed, time.Second, 10*time.Millisecond)
+
+	notifier.Stop()
+	notifier.Wait()
+	require.NoError(t, notifier.Restart())
+	_, err = notifier.Notify([]byte("after"))
+	require.NoError(t, err)
+
+	testSrv.Listener, err = net.Listen("tcp", addr)
+	require.NoError(t, err)
+	testSrv.Start()
+	defer testSrv.Close()
+
+	select {
+	case body := <-received:
+		assert.Equal(t, "after", body)
+	case <-time.After(2 * time.Second):
+		t.Fatal("message has not been sent after the server is back online")
+	}
+	notifier.Wait()
+	assert.False(t, notifier.offline.isClosed())
+}
@@ -0,0 +1,111 @@
+package notifier
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	response := func(status int, value string) *http.Response {
+		resp := &http.Response{StatusCode: status, Header: http.Header{}}
+		if value != "" {
+			resp.Header.Set("Retry-After", value)
+		}
+		return resp
+	}
+
+	tests := []struct {
+		name  string
+		resp  *http.Response
+		delay time.Duration
+		ok    bool
+	}{
+		{"Seconds", response(http.StatusTooManyRequests, "3"), 3 * time.Second, true},
+		{"HTTP date", response(http.StatusServiceUnavailable, now.Add(time.Minute).Format(http.TimeFormat)), time.Minute, true},
+		{"Date in the past", response(http.StatusServiceUnavailable, now.Add(-time.Minute).Format(http.TimeFormat)), 0, true},
+		{"No header", response(http.StatusTooManyRequests, ""), 0, false},
+		{"Invalid value", response(http.StatusTooManyRequests, "soon"), 0, false},
+		{"Negative seconds", response(http.StatusTooManyRequests, "-1"), 0, false},
+		{"Other status", response(http.StatusBadGateway, "3"), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, ok := retryAfter(tt.resp, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.delay, delay)
+		})
+	}
+}
+
+func TestNotifier_RetryAfter(t *testing.T) {
+	t.Run("Honored", func(t *testing.T) {
+		var requests int32
+		var first time.Time
+		testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if atomic.AddInt32(&requests, 1) == 1 {
+				first = time.Now()
+				writer.Header().Set("Retry-After", "1")
+				writer.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			assert.GreaterOrEqual(t, int64(time.Since(first)), int64(time.Second))
+		}))
+		defer testSrv.Close()
+
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 1,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   1,
+			MaxRetries:           1,
+			RetryBackoff:         time.Millisecond,
+			MaxRetryAfter:        time.Minute,
+		})
+		notifier.OnError(func(message []byte, err error) {
+			t.Errorf("unexpected error: %v", err)
+		})
+		_, err := notifier.Notify([]byte("message"))
+		require.NoError(t, err)
+		notifier.Wait()
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("Exceeds max", func(t *testing.T) {
+		var requests int32
+		testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			writer.Header().Set("Retry-After", "120")
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer testSrv.Close()
+
+		notifier := New(testSrv.URL, &ClientParams{
+			MaxConcurrentWorkers: 1,
+			MaxRequestRate:       time.Millisecond,
+			MaxRequestsPerRate:   1,
+			MaxRetries:           3,
+			MaxRetryAfter:        time.Second,
+		})
+		failed := make(chan error, 1)
+		notifier.OnError(func(message []byte, err error) {
+			failed <- err
+		})
+		_, err := notifier.Notify([]byte("message"))
+		require.NoError(t, err)
+		notifier.Wait()
+
+		var nErr *NotifyErr
+		require.True(t, errors.As(<-failed, &nErr))
+		assert.Equal(t, TypeSendError, nErr.Type)
+		assert.Equal(t, http.StatusServiceUnavailable, nErr.StatusCode)
+		assert.Equal(t, 1, nErr.Attempts)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+}
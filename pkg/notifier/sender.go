@@ -0,0 +1,118 @@
+package notifier
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"sync"
+)
+
+// Sender delivers messages to a destination which is not an HTTP endpoint.
+// Senders are selected by URL scheme and share workers limit and rate limiter with HTTP requests.
+type Sender interface {
+	// Send delivers single message. It's called concurrently from different workers.
+	Send(ctx context.Context, message []byte) error
+	// Close releases resources of the Sender.
+	Close() error
+}
+
+// senderFactories creates Sender for URL with the scheme.
+var senderFactories = map[string]func(u *url.URL) (Sender, error){
+	"gelf": newGELFSender,
+	"tcp":  newTCPSender,
+}
+
+// senders keeps Senders created for URLs, so connections are reused by following messages.
+type senders struct {
+	mu  sync.Mutex
+	all map[string]Sender
+}
+
+// get returns Sender for rawURL or nil if the URL should be handled by HTTP client.
+func (s *senders) get(rawURL string) (Sender, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil // HTTP client reports invalid URL.
+	}
+	factory, ok := senderFactories[u.Scheme]
+	if !ok {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sender, ok := s.all[rawURL]; ok {
+		return sender, nil
+	}
+	sender, err := factory(u)
+	if err != nil {
+		return nil, err
+	}
+	if s.all == nil {
+		s.all = make(map[string]Sender)
+	}
+	s.all[rawURL] = sender
+	return sender, nil
+}
+
+// close closes all created Senders and returns the first error.
+func (s *senders) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var firstErr error
+	for rawURL, sender := range s.all {
+		if err := sender.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(s.all, rawURL)
+	}
+	return firstErr
+}
+
+// sendWith delivers message of the task using sender. Body of readers is read to the end before sending.
+// Unlike HTTP requests messages are sent once without retries.
+func (c *Client) sendWith(ctx context.Context, sender Sender, t task, body []byte) error {
+	if t.reader != nil {
+		var err error
+		if body, err = ioutil.ReadAll(t.reader); err != nil {
+			return &NotifyErr{
+				Type:    TypeSendError,
+				Message: msgSendErrorRequest,
+				Err:     err,
+			}
+		}
+	}
+	if err := c.waitGates(ctx); err != nil {
+		return &NotifyErr{
+			Type:    TypeContextCanceled,
+			Message: "Client context canceled",
+			Err:     err,
+		}
+	}
+	if !t.rateWaited {
+		if err := c.waitRate(ctx, t.message); err != nil {
+			return &NotifyErr{
+				Type:    TypeSendError,
+				Message: msgSendErrorRateLimiter,
+				Err:     err,
+			}
+		}
+	}
+	if c.pacer != nil {
+		if err := c.pacer.wait(ctx); err != nil {
+			return &NotifyErr{
+				Type:    TypeSendError,
+				Message: msgSendErrorRateLimiter,
+				Err:     err,
+			}
+		}
+	}
+	if err := sender.Send(ctx, body); err != nil {
+		return &NotifyErr{
+			Type:    TypeSendError,
+			Message: msgSendErrorClient,
+			Err:     err,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package notifier
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// sendShadow sends copy of the task message to the shadow URL.
+// It doesn't retry and ignores any errors, so primary delivery is not affected.
+func (c *Client) sendShadow(t task) {
+	defer c.workers.Done()
+	body, contentType, err := c.encoder.Encode(t.message)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(c.context(), c.method, c.shadowURL, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	setHeaders(req, c.headers)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return
+	}
+	closeResponse(resp)
+}
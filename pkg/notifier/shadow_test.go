@@ -0,0 +1,63 @@
+package notifier
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_ShadowURL(t *testing.T) {
+	type server struct {
+		*httptest.Server
+		mu       sync.Mutex
+		received []string
+	}
+	newServer := func(status int) *server {
+		s := &server{}
+		s.Server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			body, _ := ioutil.ReadAll(request.Body)
+			s.mu.Lock()
+			s.received = append(s.received, string(body))
+			s.mu.Unlock()
+			writer.WriteHeader(status)
+		}))
+		return s
+	}
+	primary := newServer(http.StatusOK)
+	defer primary.Close()
+	shadow := newServer(http.StatusInternalServerError)
+	defer shadow.Close()
+
+	notifier := New(primary.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+		MaxRetries:           3,
+		DeadLetterCapacity:   10,
+		ShadowURL:            shadow.URL,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	n, err := notifier.Notify([]byte("1"), []byte("2"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	notifier.Wait()
+
+	for _, s := range []*server{primary, shadow} {
+		s.mu.Lock()
+		sort.Strings(s.received)
+		assert.Equal(t, []string{"1", "2"}, s.received)
+		s.mu.Unlock()
+	}
+	assert.Empty(t, notifier.DeadLetters())
+	assert.Equal(t, uint64(2), notifier.metrics.succeeded)
+	assert.Equal(t, uint64(0), notifier.metrics.failed)
+}
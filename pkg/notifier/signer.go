@@ -0,0 +1,10 @@
+package notifier
+
+import "net/http"
+
+// Signer signs outgoing requests, e.g. to authenticate them with a gateway.
+// Sign is called for every attempt right before the request is sent, so it can use fresh credentials.
+// body is the exact request body which is going to be sent.
+type Signer interface {
+	Sign(req *http.Request, body []byte) error
+}
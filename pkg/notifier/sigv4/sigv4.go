@@ -0,0 +1,178 @@
+// Package sigv4 provides AWS Signature Version 4 request signer for the notifier library.
+//
+// It is kept in a separate package to keep the core library free of AWS specifics.
+// It implements signing process described at https://docs.aws.amazon.com/general/latest/gr/signature-version-4.html
+// without AWS SDK dependency.
+package sigv4
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	algorithm       = "AWS4-HMAC-SHA256"
+	timeFormat      = "20060102T150405Z"
+	shortTimeFormat = "20060102"
+)
+
+// Credentials AWS credentials used to sign requests.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// CredentialsProvider provides credentials for every signed request.
+// Implementations are responsible for credentials rotation.
+type CredentialsProvider interface {
+	Credentials() (Credentials, error)
+}
+
+// StaticCredentials provides the same credentials for every request.
+type StaticCredentials Credentials
+
+// Credentials implements CredentialsProvider interface.
+func (s StaticCredentials) Credentials() (Credentials, error) {
+	return Credentials(s), nil
+}
+
+// CredentialsFunc adapts function to CredentialsProvider interface.
+// It can be used to provide rotating credentials.
+type CredentialsFunc func() (Credentials, error)
+
+// Credentials implements CredentialsProvider interface.
+func (f CredentialsFunc) Credentials() (Credentials, error) {
+	return f()
+}
+
+// Signer signs requests using AWS Signature Version 4.
+// It implements notifier.Signer interface.
+type Signer struct {
+	region   string
+	service  string
+	provider CredentialsProvider
+	now      func() time.Time
+}
+
+// New creates new Signer for region and service (e.g. "execute-api" for API Gateway).
+// provider is asked for credentials every time request is signed.
+func New(region, service string, provider CredentialsProvider) *Signer {
+	return &Signer{
+		region:   region,
+		service:  service,
+		provider: provider,
+		now:      time.Now,
+	}
+}
+
+// Sign adds X-Amz-Date, X-Amz-Security-Token (for temporary credentials) and Authorization headers to the request.
+func (s *Signer) Sign(req *http.Request, body []byte) error {
+	creds, err := s.provider.Credentials()
+	if err != nil {
+		return fmt.Errorf("unable to get credentials: %w", err)
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return errors.New("empty credentials")
+	}
+
+	t := s.now().UTC()
+	req.Header.Set("X-Amz-Date", t.Format(timeFormat))
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers, signedHeaders := canonicalHeaders(req)
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQuery(req.URL),
+		headers,
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := strings.Join([]string{t.Format(shortTimeFormat), s.region, s.service, "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{algorithm, t.Format(timeFormat), scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), t.Format(shortTimeFormat))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// canonicalURI returns URI-encoded path of the request.
+func canonicalURI(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// canonicalQuery returns query string sorted by parameter names and values.
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	params := make([]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			params = append(params, escape(key)+"="+escape(value))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// canonicalHeaders returns canonical headers and signed headers list.
+// It signs Host, Content-Type and all X-Amz-* headers.
+func canonicalHeaders(req *http.Request) (headers, signedHeaders string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string]string{"host": host}
+	for name, v := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			values[lower] = strings.Join(strings.Fields(strings.Join(v, ",")), " ")
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + values[name] + "\n")
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+// escape escapes string according to RFC 3986 as required by AWS.
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data)) //nolint: errcheck, gosec
+	return h.Sum(nil)
+}
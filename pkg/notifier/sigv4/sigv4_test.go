@@ -0,0 +1,78 @@
+package sigv4
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/idexter/notifier-test-task/pkg/notifier"
+)
+
+var authorizationRe = regexp.MustCompile(
+	`^AWS4-HMAC-SHA256 Credential=([A-Z0-9]+)/\d{8}/us-east-1/execute-api/aws4_request, ` +
+		`SignedHeaders=([a-z0-9;-]+), Signature=[0-9a-f]{64}$`)
+
+func TestSigner_Sign(t *testing.T) {
+	// Test vector "get-vanilla" from AWS Signature Version 4 test suite.
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+
+	signer := New("us-east-1", "service", StaticCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	})
+	signer.now = func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+
+	require.NoError(t, signer.Sign(req, nil))
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestSigner_Notifier(t *testing.T) {
+	keys := make(chan string, 2)
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		matches := authorizationRe.FindStringSubmatch(request.Header.Get("Authorization"))
+		if assert.Len(t, matches, 3) {
+			keys <- matches[1]
+			assert.Equal(t, "host;x-amz-date;x-amz-security-token", matches[2])
+		}
+		assert.NotEmpty(t, request.Header.Get("X-Amz-Date"))
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer testSrv.Close()
+
+	var calls int32
+	rotating := CredentialsFunc(func() (Credentials, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return Credentials{AccessKeyID: "FIRSTKEY", SecretAccessKey: "secret", SessionToken: "token"}, nil
+		}
+		return Credentials{AccessKeyID: "SECONDKEY", SecretAccessKey: "secret", SessionToken: "token"}, nil
+	})
+
+	client := notifier.New(testSrv.URL, &notifier.ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+		Signer:               New("us-east-1", "execute-api", rotating),
+	})
+	client.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	_, err := client.Notify([]byte("first"))
+	require.NoError(t, err)
+	client.Wait()
+	_, err = client.Notify([]byte("second"))
+	require.NoError(t, err)
+	client.Wait()
+
+	assert.Equal(t, "FIRSTKEY", <-keys)
+	assert.Equal(t, "SECONDKEY", <-keys)
+}
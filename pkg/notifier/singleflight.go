@@ -0,0 +1,32 @@
+package notifier
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// sendShared sends message of the task. If SingleFlight is enabled and the same message
+// is already being sent to the same URL it waits for that send and returns its result.
+// Tasks with own headers, idempotency key or context are never shared, because their requests
+// or cancellation differ from tasks with the same message.
+func (c *Client) sendShared(t task) (int, error) {
+	if c.flight == nil || t.reader != nil || len(t.headers) > 0 || t.idempotencyKey != "" || t.ctx != nil {
+		return c.send(t)
+	}
+	statusCode, err, _ := c.flight.Do(flightKey(t), func() (interface{}, error) {
+		return c.send(t)
+	})
+	return statusCode.(int), err
+}
+
+// flightKey returns key identifying request of the task.
+func flightKey(t task) string {
+	h := sha256.New()
+	h.Write([]byte(t.url))                       //nolint: errcheck, gosec
+	h.Write([]byte{0})                           //nolint: errcheck, gosec
+	h.Write([]byte(strconv.Itoa(t.repeatCount))) //nolint: errcheck, gosec
+	h.Write([]byte{0})                           //nolint: errcheck, gosec
+	h.Write(t.message)                           //nolint: errcheck, gosec
+	return hex.EncodeToString(h.Sum(nil))
+}
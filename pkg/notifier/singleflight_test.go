@@ -0,0 +1,88 @@
+package notifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_SingleFlight(t *testing.T) {
+	var requests int32
+	received := make(chan struct{}, 2)
+	release := make(chan struct{})
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		received <- struct{}{}
+		<-release
+		writer.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+		SingleFlight:         true,
+	})
+	var notified int32
+	notifier.OnError(func(message []byte, err error) {
+		assert.Equal(t, "message", string(message))
+		assert.Error(t, err)
+		atomic.AddInt32(&notified, 1)
+	})
+
+	_, err := notifier.Notify([]byte("message"))
+	require.NoError(t, err)
+	<-received
+	_, err = notifier.Notify([]byte("message"))
+	require.NoError(t, err)
+	// Give the second worker time to join in-flight send.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	notifier.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&notified))
+}
+
+func TestNotifier_SingleFlightItems(t *testing.T) {
+	received := make(chan string, 2)
+	release := make(chan struct{})
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		received <- request.Header.Get("X-Tenant")
+		<-release
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 2,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   2,
+		SingleFlight:         true,
+	})
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+
+	_, err := notifier.NotifyItems(Item{Body: []byte("message"), Headers: http.Header{"X-Tenant": {"a"}}})
+	require.NoError(t, err)
+	first := <-received
+	// Items with the same body but different headers are sent separately.
+	_, err = notifier.NotifyItems(Item{Body: []byte("message"), Headers: http.Header{"X-Tenant": {"b"}}})
+	require.NoError(t, err)
+	var second string
+	select {
+	case second = <-received:
+	case <-time.After(time.Second):
+		t.Fatal("item with different headers must not join in-flight send")
+	}
+	close(release)
+	notifier.Wait()
+
+	assert.ElementsMatch(t, []string{"a", "b"}, []string{first, second})
+}
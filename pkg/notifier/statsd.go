@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// statsdEmitter writes per message metrics to statsd endpoint using statsd line protocol.
+type statsdEmitter struct {
+	conn   net.Conn
+	prefix string
+}
+
+// EnableStatsd enables emitting of per message metrics to statsd (or DogStatsD) endpoint at addr over UDP.
+// For every message it emits "sent" or "failed" counter and "latency" timer in milliseconds.
+// Metric names are prefixed with prefix and a dot if prefix is not empty.
+// It's safe to call it while messages are being sent, the connection of the previous call is closed.
+func (c *Client) EnableStatsd(addr, prefix string) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return fmt.Errorf("unable to connect to statsd: %w", err)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+	c.statsdMu.Lock()
+	old := c.statsd
+	c.statsd = &statsdEmitter{conn: conn, prefix: prefix}
+	c.statsdMu.Unlock()
+	if old != nil {
+		_ = old.conn.Close()
+	}
+	return nil
+}
+
+// statsdEmitter returns emitter set by EnableStatsd or nil.
+func (c *Client) statsdEmitter() *statsdEmitter {
+	c.statsdMu.RLock()
+	defer c.statsdMu.RUnlock()
+	return c.statsd
+}
+
+// emit sends metrics of single message in one packet. Errors are ignored as statsd is best-effort.
+func (s *statsdEmitter) emit(failed bool, latency time.Duration) {
+	counter := "sent"
+	if failed {
+		counter = "failed"
+	}
+	packet := fmt.Sprintf("%s%s:1|c\n%slatency:%d|ms", s.prefix, counter, s.prefix, latency.Milliseconds())
+	_, _ = s.conn.Write([]byte(packet))
+}
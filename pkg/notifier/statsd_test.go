@@ -0,0 +1,82 @@
+package notifier
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier_EnableStatsd(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	testSrv := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/fail" {
+			writer.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer testSrv.Close()
+
+	notifier := New(testSrv.URL, &ClientParams{
+		MaxConcurrentWorkers: 1,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   1,
+	})
+	require.NoError(t, notifier.EnableStatsd(listener.LocalAddr().String(), "notifier"))
+
+	readPacket := func() string {
+		require.NoError(t, listener.SetReadDeadline(time.Now().Add(time.Second)))
+		buf := make([]byte, 512)
+		n, _, err := listener.ReadFrom(buf)
+		require.NoError(t, err)
+		return string(buf[:n])
+	}
+	latencyRe := regexp.MustCompile(`^notifier\.latency:\d+\|ms$`)
+
+	_, err = notifier.Notify([]byte("ok"))
+	require.NoError(t, err)
+	notifier.Wait()
+	lines := strings.Split(readPacket(), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "notifier.sent:1|c", lines[0])
+	assert.Regexp(t, latencyRe, lines[1])
+
+	_, err = notifier.NotifyTo(testSrv.URL+"/fail", []byte("fail"))
+	require.NoError(t, err)
+	notifier.Wait()
+	lines = strings.Split(readPacket(), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "notifier.failed:1|c", lines[0])
+	assert.Regexp(t, latencyRe, lines[1])
+	t.Run("Replaced while sending", func(t *testing.T) {
+		replacement, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer replacement.Close()
+
+		old := notifier.statsdEmitter()
+		_, err = notifier.Notify([]byte("ok"))
+		require.NoError(t, err)
+		require.NoError(t, notifier.EnableStatsd(replacement.LocalAddr().String(), "replaced"))
+		notifier.Wait()
+
+		_, err = old.conn.Write([]byte("closed"))
+		assert.Error(t, err, "connection of the previous call must be closed")
+
+		_, err = notifier.Notify([]byte("ok"))
+		require.NoError(t, err)
+		notifier.Wait()
+		require.NoError(t, replacement.SetReadDeadline(time.Now().Add(time.Second)))
+		buf := make([]byte, 512)
+		n, _, err := replacement.ReadFrom(buf)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(buf[:n]), "replaced.sent:1|c\n"))
+	})
+}
@@ -0,0 +1,117 @@
+package notifier
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// aliveCheckTimeout is how long alive check waits for the sink closing connection.
+const aliveCheckTimeout = time.Millisecond
+
+// tcpSender sends messages to a raw TCP sink selected by "tcp://host:port" URL.
+// Every message is written as 4 bytes big-endian length followed by the message bytes.
+// Messages are written one by one through a single persistent connection,
+// which is re-established if writing fails.
+type tcpSender struct {
+	addr string
+
+	mu   sync.Mutex
+	conn net.Conn
+	buf  []byte
+}
+
+// newTCPSender creates tcpSender for the URL. Connection is established by the first Send.
+func newTCPSender(u *url.URL) (Sender, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("tcp sink address is empty")
+	}
+	return &tcpSender{addr: u.Host}, nil
+}
+
+// Send implements Sender interface.
+// If writing to an existing connection fails the message is written once more using a new connection.
+func (s *tcpSender) Send(ctx context.Context, message []byte) error {
+	if uint64(len(message)) > math.MaxUint32 {
+		return fmt.Errorf("message of %d bytes is too large", len(message))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf = append(s.buf[:0], 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(s.buf, uint32(len(message)))
+	s.buf = append(s.buf, message...)
+
+	if s.conn != nil && !s.alive() {
+		s.reset()
+	}
+	reused := s.conn != nil
+	err := s.write(ctx)
+	if err != nil && reused && ctx.Err() == nil {
+		// Connection could be closed by the sink while it was idle.
+		err = s.write(ctx)
+	}
+	return err
+}
+
+// write writes buffer to the connection establishing it if needed. Connection is closed on error.
+func (s *tcpSender) write(ctx context.Context) error {
+	if s.conn == nil {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", s.addr)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	deadline, _ := ctx.Deadline() // Zero deadline means no deadline.
+	if err := s.conn.SetWriteDeadline(deadline); err != nil {
+		s.reset()
+		return err
+	}
+	if _, err := s.conn.Write(s.buf); err != nil {
+		s.reset()
+		return err
+	}
+	return nil
+}
+
+// alive reports whether the connection hasn't been closed by the sink.
+// The sink isn't expected to send anything, so the connection is alive if short read times out.
+// Already expired deadline isn't used, because then read fails without checking the socket.
+func (s *tcpSender) alive() bool {
+	if err := s.conn.SetReadDeadline(time.Now().Add(aliveCheckTimeout)); err != nil {
+		return false
+	}
+	var b [1]byte
+	_, err := s.conn.Read(b[:])
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		return false
+	}
+	return s.conn.SetReadDeadline(time.Time{}) == nil
+}
+
+// reset closes current connection, so the next write establishes a new one.
+func (s *tcpSender) reset() {
+	s.conn.Close() //nolint: errcheck, gosec
+	s.conn = nil
+}
+
+// Close implements Sender interface.
+func (s *tcpSender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
@@ -0,0 +1,74 @@
+package notifier
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readFramedMessages reads length-prefixed messages from conn until it's closed.
+func readFramedMessages(conn net.Conn, messages chan<- string) {
+	defer conn.Close() //nolint: errcheck
+	var header [4]byte
+	for {
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			return
+		}
+		message := make([]byte, binary.BigEndian.Uint32(header[:]))
+		if _, err := io.ReadFull(conn, message); err != nil {
+			return
+		}
+		messages <- string(message)
+	}
+}
+
+func TestNotifier_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close() //nolint: errcheck
+
+	messages := make(chan string, 10)
+	conns := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+			go readFramedMessages(conn, messages)
+		}
+	}()
+
+	notifier := New("tcp://"+listener.Addr().String(), &ClientParams{
+		MaxConcurrentWorkers: 4,
+		MaxRequestRate:       time.Millisecond,
+		MaxRequestsPerRate:   4,
+		BlockWhenFull:        true,
+	})
+	defer notifier.Close() //nolint: errcheck
+	notifier.OnError(func(message []byte, err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+
+	sent := []string{"first", "", "third message"}
+	for _, message := range sent {
+		require.Empty(t, notifier.NotifyAndWait([]byte(message))[0])
+		assert.Equal(t, message, <-messages)
+	}
+	assert.Len(t, conns, 1, "connection should be reused")
+
+	// Sink closes the connection, so the sender has to reconnect.
+	require.NoError(t, (<-conns).Close())
+	time.Sleep(50 * time.Millisecond)
+	for _, message := range []string{"after reconnect 1", "after reconnect 2"} {
+		require.Empty(t, notifier.NotifyAndWait([]byte(message))[0])
+		assert.Equal(t, message, <-messages)
+	}
+	assert.Len(t, conns, 1)
+}